@@ -0,0 +1,277 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package board implements a Go board with the basic rules
+// of stone placement, capturing, suicide and simple ko.
+package board
+
+import (
+	"errors"
+
+	"github.com/yagoggame/api"
+)
+
+// Colour is a colour of a stone on the board.
+type Colour int
+
+const (
+	// Empty marks a free intersection.
+	Empty Colour = iota
+	// Black marks a black stone.
+	Black
+	// White marks a white stone.
+	White
+)
+
+// Opponent returns the colour of the other player.
+func (c Colour) Opponent() Colour {
+	switch c {
+	case Black:
+		return White
+	case White:
+		return Black
+	}
+	return Empty
+}
+
+func (c Colour) String() string {
+	switch c {
+	case Black:
+		return "black"
+	case White:
+		return "white"
+	}
+	return "empty"
+}
+
+var (
+	// ErrOutOfBoard is returned when a point is outside of the board.
+	ErrOutOfBoard = errors.New("point is out of the board")
+	// ErrOccupied is returned when an intersection is already occupied.
+	ErrOccupied = errors.New("intersection is occupied")
+	// ErrSuicide is returned when a stone would have no liberties.
+	ErrSuicide = errors.New("suicide is not allowed")
+	// ErrKo is returned when a move immediately retakes a ko.
+	ErrKo = errors.New("ko retake is not allowed")
+	// ErrNoColour is returned when a stone of Empty colour is played.
+	ErrNoColour = errors.New("stone has no colour")
+)
+
+// Point is an intersection of the board.
+// Coordinates are 1-based, as in api.TurnMessage.
+type Point struct {
+	X, Y int
+}
+
+// Board holds stones placed on the board.
+type Board struct {
+	size  int
+	cells [][]Colour
+	ko    *Point
+}
+
+// New creates an empty board of size x size intersections.
+func New(size int) *Board {
+	cells := make([][]Colour, size)
+	for i := range cells {
+		cells[i] = make([]Colour, size)
+	}
+	return &Board{size: size, cells: cells}
+}
+
+// FromState creates a board with stones from the state.
+// Stones are put as is, without rules checking.
+func FromState(state *api.State) *Board {
+	b := New(int(state.GetSize()))
+	for _, p := range state.GetBlack().GetChipsOnBoard() {
+		b.put(Point{X: int(p.X), Y: int(p.Y)}, Black)
+	}
+	for _, p := range state.GetWhite().GetChipsOnBoard() {
+		b.put(Point{X: int(p.X), Y: int(p.Y)}, White)
+	}
+	return b
+}
+
+// Size returns the size of the board.
+func (b *Board) Size() int {
+	return b.size
+}
+
+// Inside reports whether the point is on the board.
+func (b *Board) Inside(p Point) bool {
+	return p.X >= 1 && p.Y >= 1 && p.X <= b.size && p.Y <= b.size
+}
+
+// At returns the colour of the stone at the point.
+func (b *Board) At(p Point) Colour {
+	if !b.Inside(p) {
+		return Empty
+	}
+	return b.cells[p.Y-1][p.X-1]
+}
+
+// Stones returns all stones of the colour, ordered by rows.
+func (b *Board) Stones(c Colour) []Point {
+	var stones []Point
+	for y := range b.cells {
+		for x := range b.cells[y] {
+			if b.cells[y][x] == c {
+				stones = append(stones, Point{X: x + 1, Y: y + 1})
+			}
+		}
+	}
+	return stones
+}
+
+// Clone returns a deep copy of the board.
+func (b *Board) Clone() *Board {
+	c := New(b.size)
+	for y := range b.cells {
+		copy(c.cells[y], b.cells[y])
+	}
+	if b.ko != nil {
+		ko := *b.ko
+		c.ko = &ko
+	}
+	return c
+}
+
+// Put places the stone of colour c at p as is, without rules checking,
+// e.g. a setup stone of a game record. Empty colour removes the stone.
+// The ko, if any, is not forbidden anymore.
+func (b *Board) Put(c Colour, p Point) error {
+	if !b.Inside(p) {
+		return ErrOutOfBoard
+	}
+	b.put(p, c)
+	b.ko = nil
+	return nil
+}
+
+// Check reports whether the stone of colour c may be played at p.
+func (b *Board) Check(c Colour, p Point) error {
+	_, err := b.Clone().Play(c, p)
+	return err
+}
+
+// Play places the stone of colour c at p and removes captured stones.
+// It returns the captured stones.
+func (b *Board) Play(c Colour, p Point) ([]Point, error) {
+	switch {
+	case c == Empty:
+		return nil, ErrNoColour
+	case !b.Inside(p):
+		return nil, ErrOutOfBoard
+	case b.At(p) != Empty:
+		return nil, ErrOccupied
+	case b.ko != nil && *b.ko == p:
+		return nil, ErrKo
+	}
+
+	b.put(p, c)
+	var captured []Point
+	for _, n := range b.neighbours(p) {
+		if b.At(n) != c.Opponent() {
+			continue
+		}
+		group, liberties := b.group(n)
+		if liberties == 0 {
+			for _, s := range group {
+				b.put(s, Empty)
+			}
+			captured = append(captured, group...)
+		}
+	}
+
+	group, liberties := b.group(p)
+	if liberties == 0 {
+		b.put(p, Empty)
+		return nil, ErrSuicide
+	}
+
+	b.ko = nil
+	if len(captured) == 1 && len(group) == 1 && liberties == 1 {
+		ko := captured[0]
+		b.ko = &ko
+	}
+	return captured, nil
+}
+
+func (b *Board) put(p Point, c Colour) {
+	if b.Inside(p) {
+		b.cells[p.Y-1][p.X-1] = c
+	}
+}
+
+func (b *Board) neighbours(p Point) []Point {
+	candidates := []Point{{p.X - 1, p.Y}, {p.X + 1, p.Y}, {p.X, p.Y - 1}, {p.X, p.Y + 1}}
+	rez := candidates[:0]
+	for _, n := range candidates {
+		if b.Inside(n) {
+			rez = append(rez, n)
+		}
+	}
+	return rez
+}
+
+// group returns the chain of stones connected with p and number of its liberties.
+func (b *Board) group(p Point) ([]Point, int) {
+	c := b.At(p)
+	visited := map[Point]bool{p: true}
+	liberties := make(map[Point]bool)
+	stack := []Point{p}
+	var group []Point
+
+	for len(stack) > 0 {
+		cur := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		group = append(group, cur)
+		for _, n := range b.neighbours(cur) {
+			switch b.At(n) {
+			case Empty:
+				liberties[n] = true
+			case c:
+				if !visited[n] {
+					visited[n] = true
+					stack = append(stack, n)
+				}
+			}
+		}
+	}
+	return group, len(liberties)
+}
+
+// Diff compares the board with the previous one.
+// It returns stones which are added to the board
+// and stones which are removed from it, e.g. captured.
+func (b *Board) Diff(prev *Board) (added, removed []Point) {
+	for y := 1; y <= b.size; y++ {
+		for x := 1; x <= b.size; x++ {
+			p := Point{X: x, Y: y}
+			cur, old := b.At(p), prev.At(p)
+			if cur == old {
+				continue
+			}
+			if old != Empty {
+				removed = append(removed, p)
+			}
+			if cur != Empty {
+				added = append(added, p)
+			}
+		}
+	}
+	return added, removed
+}
@@ -0,0 +1,242 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package board
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// parseBoard creates the board from rows, where "X" is a black stone,
+// "O" is a white one and "." is a free intersection.
+func parseBoard(t *testing.T, rows ...string) *Board {
+	t.Helper()
+	b := New(len(rows))
+	for y, row := range rows {
+		if len(row) != len(rows) {
+			t.Fatalf("row %q of the board %d x %d", row, len(rows), len(rows))
+		}
+		for x, c := range row {
+			switch c {
+			case 'X':
+				b.put(Point{X: x + 1, Y: y + 1}, Black)
+			case 'O':
+				b.put(Point{X: x + 1, Y: y + 1}, White)
+			}
+		}
+	}
+	return b
+}
+
+// drawBoard draws the board in the format of parseBoard.
+func drawBoard(b *Board) string {
+	var rows []string
+	for y := range b.cells {
+		var row strings.Builder
+		for x := range b.cells[y] {
+			row.WriteByte(".XO"[b.cells[y][x]])
+		}
+		rows = append(rows, row.String())
+	}
+	return strings.Join(rows, "\n")
+}
+
+func TestPlay(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		board    []string
+		colour   Colour
+		p        Point
+		want     []string
+		captured []Point
+		err      error
+	}{
+		{
+			name:   "free point",
+			board:  []string{"...", "...", "..."},
+			colour: Black, p: Point{X: 2, Y: 2},
+			want: []string{"...", ".X.", "..."},
+		},
+		{
+			name:   "single stone captured",
+			board:  []string{".X.", "XO.", ".X."},
+			colour: Black, p: Point{X: 3, Y: 2},
+			want:     []string{".X.", "X.X", ".X."},
+			captured: []Point{{X: 2, Y: 2}},
+		},
+		{
+			name:   "stone captured in the corner",
+			board:  []string{"O..", "X..", "..."},
+			colour: Black, p: Point{X: 2, Y: 1},
+			want:     []string{".X.", "X..", "..."},
+			captured: []Point{{X: 1, Y: 1}},
+		},
+		{
+			name:   "group captured at the edge",
+			board:  []string{"OO.X", "XX..", "....", "...."},
+			colour: Black, p: Point{X: 3, Y: 1},
+			want:     []string{"..XX", "XX..", "....", "...."},
+			captured: []Point{{X: 1, Y: 1}, {X: 2, Y: 1}},
+		},
+		{
+			name:   "two groups captured",
+			board:  []string{"O.O", "XOX", ".X."},
+			colour: Black, p: Point{X: 2, Y: 1},
+			want:     []string{".X.", "X.X", ".X."},
+			captured: []Point{{X: 1, Y: 1}, {X: 2, Y: 2}, {X: 3, Y: 1}},
+		},
+		{
+			name:   "suicide of a single stone",
+			board:  []string{".O.", "O..", "..."},
+			colour: Black, p: Point{X: 1, Y: 1},
+			want: []string{".O.", "O..", "..."},
+			err:  ErrSuicide,
+		},
+		{
+			name:   "suicide of a group",
+			board:  []string{"X.O", "OO.", "..."},
+			colour: Black, p: Point{X: 2, Y: 1},
+			want: []string{"X.O", "OO.", "..."},
+			err:  ErrSuicide,
+		},
+		{
+			name:   "capture is not a suicide",
+			board:  []string{".OX", "OX.", "X.."},
+			colour: Black, p: Point{X: 1, Y: 1},
+			want:     []string{"X.X", ".X.", "X.."},
+			captured: []Point{{X: 1, Y: 2}, {X: 2, Y: 1}},
+		},
+		{
+			name:   "occupied",
+			board:  []string{"...", ".O.", "..."},
+			colour: Black, p: Point{X: 2, Y: 2},
+			want: []string{"...", ".O.", "..."},
+			err:  ErrOccupied,
+		},
+		{
+			name:   "out of the board",
+			board:  []string{"...", "...", "..."},
+			colour: White, p: Point{X: 4, Y: 1},
+			want: []string{"...", "...", "..."},
+			err:  ErrOutOfBoard,
+		},
+		{
+			name:   "no colour",
+			board:  []string{"...", "...", "..."},
+			colour: Empty, p: Point{X: 1, Y: 1},
+			want: []string{"...", "...", "..."},
+			err:  ErrNoColour,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := parseBoard(t, tc.board...)
+			captured, err := b.Play(tc.colour, tc.p)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("Play: got error %v, want %v", err, tc.err)
+			}
+			if !samePoints(captured, tc.captured) {
+				t.Errorf("captured: got %v, want %v", captured, tc.captured)
+			}
+			if got, want := drawBoard(b), drawBoard(parseBoard(t, tc.want...)); got != want {
+				t.Errorf("board:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestKo(t *testing.T) {
+	b := parseBoard(t,
+		".XO..",
+		"XO.O.",
+		".XO..",
+		".....",
+		".....",
+	)
+	ko := Point{X: 3, Y: 2}
+	captured, err := b.Play(Black, ko)
+	if err != nil || !samePoints(captured, []Point{{X: 2, Y: 2}}) {
+		t.Fatalf("Play: got %v, %v, want the stone at 2 2 captured", captured, err)
+	}
+
+	// the immediate retake is forbidden, everywhere else may be played.
+	retake := Point{X: 2, Y: 2}
+	if err := b.Check(White, retake); !errors.Is(err, ErrKo) {
+		t.Fatalf("immediate retake: got %v, want ErrKo", err)
+	}
+	if _, err := b.Play(White, Point{X: 5, Y: 5}); err != nil {
+		t.Fatalf("threat: %v", err)
+	}
+	if _, err := b.Play(Black, Point{X: 4, Y: 4}); err != nil {
+		t.Fatalf("answer: %v", err)
+	}
+	// the ko may be retaken after the exchange elsewhere.
+	captured, err = b.Play(White, retake)
+	if err != nil || !samePoints(captured, []Point{ko}) {
+		t.Fatalf("retake after the exchange: got %v, %v, want the stone at 3 2 captured", captured, err)
+	}
+}
+
+func TestKoIsNotSnapback(t *testing.T) {
+	// the capture of two stones is not a ko, it may be answered at once.
+	b := parseBoard(t,
+		".XXO.",
+		"XOO.O",
+		".XXO.",
+		".....",
+		".....",
+	)
+	captured, err := b.Play(Black, Point{X: 4, Y: 2})
+	if err != nil || len(captured) != 2 {
+		t.Fatalf("Play: got %v, %v, want two stones captured", captured, err)
+	}
+	if err := b.Check(White, Point{X: 3, Y: 2}); err != nil {
+		t.Errorf("the answer to the capture of two stones: %v", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	prev := parseBoard(t, "X..", ".O.", "...")
+	cur := parseBoard(t, "X.O", "...", "..X")
+
+	added, removed := cur.Diff(prev)
+	if want := []Point{{X: 3, Y: 1}, {X: 3, Y: 3}}; !reflect.DeepEqual(added, want) {
+		t.Errorf("added: got %v, want %v", added, want)
+	}
+	if want := []Point{{X: 2, Y: 2}}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed: got %v, want %v", removed, want)
+	}
+}
+
+// samePoints reports whether a and b have the same points in any order.
+func samePoints(a, b []Point) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	count := make(map[Point]int)
+	for _, p := range a {
+		count[p]++
+	}
+	for _, p := range b {
+		count[p]--
+		if count[p] < 0 {
+			return false
+		}
+	}
+	return true
+}
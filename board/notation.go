@@ -0,0 +1,65 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package board
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// columnLetters are labels of the columns, the letter I is skipped by tradition.
+const columnLetters = "ABCDEFGHJKLMNOPQRSTUVWXYZ"
+
+// ColumnLabel returns the letter of the column x.
+func ColumnLabel(x int) string {
+	if x < 1 || x > len(columnLetters) {
+		return "?"
+	}
+	return columnLetters[x-1 : x]
+}
+
+// RowLabel returns the number of the row y in the standard notation.
+// Rows are counted from the bottom of the board, while y is counted from the top.
+func RowLabel(y, size int) int {
+	return size - y + 1
+}
+
+// Notation returns the point in the standard notation, e.g. "D4".
+func (p Point) Notation(size int) string {
+	return fmt.Sprintf("%s%d", ColumnLabel(p.X), RowLabel(p.Y, size))
+}
+
+// ParseNotation parses the point in the standard notation, e.g. "D4" or "q16".
+func ParseNotation(s string, size int) (Point, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) < 2 {
+		return Point{}, fmt.Errorf("wrong notation %q", s)
+	}
+
+	x := strings.IndexByte(columnLetters, s[0]) + 1
+	row, err := strconv.Atoi(s[1:])
+	if x == 0 || err != nil {
+		return Point{}, fmt.Errorf("wrong notation %q", s)
+	}
+
+	p := Point{X: x, Y: RowLabel(row, size)}
+	if p.X > size || row < 1 || row > size {
+		return Point{}, fmt.Errorf("%q: %w", s, ErrOutOfBoard)
+	}
+	return p, nil
+}
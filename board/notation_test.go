@@ -0,0 +1,113 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package board
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestColumnLabel(t *testing.T) {
+	for _, tc := range []struct {
+		x    int
+		want string
+	}{
+		{1, "A"},
+		{8, "H"},
+		// the letter I is skipped.
+		{9, "J"},
+		{19, "T"},
+		{25, "Z"},
+		{0, "?"},
+		{26, "?"},
+	} {
+		if got := ColumnLabel(tc.x); got != tc.want {
+			t.Errorf("ColumnLabel(%d): got %q, want %q", tc.x, got, tc.want)
+		}
+	}
+}
+
+func TestRowLabel(t *testing.T) {
+	for _, tc := range []struct {
+		y, size, want int
+	}{
+		{1, 19, 19},
+		{19, 19, 1},
+		{4, 9, 6},
+		{1, 1, 1},
+	} {
+		if got := RowLabel(tc.y, tc.size); got != tc.want {
+			t.Errorf("RowLabel(%d, %d): got %d, want %d", tc.y, tc.size, got, tc.want)
+		}
+	}
+}
+
+func TestParseNotation(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		size int
+		want Point
+	}{
+		{"A1", 19, Point{X: 1, Y: 19}},
+		{"D4", 19, Point{X: 4, Y: 16}},
+		{"q16", 19, Point{X: 16, Y: 4}},
+		{"T19", 19, Point{X: 19, Y: 1}},
+		{"J9", 9, Point{X: 9, Y: 1}},
+		{" e5 ", 9, Point{X: 5, Y: 5}},
+		{"A5", 5, Point{X: 1, Y: 1}},
+		{"Z25", 25, Point{X: 25, Y: 1}},
+	} {
+		got, err := ParseNotation(tc.s, tc.size)
+		if err != nil || got != tc.want {
+			t.Errorf("ParseNotation(%q, %d): got %v, %v, want %v", tc.s, tc.size, got, err, tc.want)
+		}
+		if err == nil && got.Notation(tc.size) != strings.ToUpper(strings.TrimSpace(tc.s)) {
+			t.Errorf("Notation of %v: got %q, want %q", got, got.Notation(tc.size), strings.ToUpper(strings.TrimSpace(tc.s)))
+		}
+	}
+}
+
+func TestParseNotationErrors(t *testing.T) {
+	for _, tc := range []struct {
+		s          string
+		size       int
+		outOfBoard bool
+	}{
+		{"", 19, false},
+		{"D", 19, false},
+		{"4D", 19, false},
+		// the letter I is not used.
+		{"I5", 19, false},
+		{"D4.5", 19, false},
+		{"D-4", 19, true},
+		{"3 3", 19, false},
+		{"K1", 9, true},
+		{"A10", 9, true},
+		{"A0", 9, true},
+		{"T19", 13, true},
+	} {
+		_, err := ParseNotation(tc.s, tc.size)
+		if err == nil {
+			t.Errorf("ParseNotation(%q, %d): no error", tc.s, tc.size)
+			continue
+		}
+		if got := errors.Is(err, ErrOutOfBoard); got != tc.outOfBoard {
+			t.Errorf("ParseNotation(%q, %d): got %v, out of board %t", tc.s, tc.size, err, tc.outOfBoard)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+
+	"github.com/yagoggame/api"
+)
+
+// RegisterUser registers the user of the connection on the service.
+func RegisterUser(ctx context.Context, client api.GoGameClient) error {
+	if _, err := client.RegisterUser(ctx, &api.EmptyMessage{}); err != nil {
+		return newStatusError("RegisterUser", err, nil)
+	}
+	return nil
+}
+
+// RemoveUser removes the user of the connection from the service.
+func RemoveUser(ctx context.Context, client api.GoGameClient) error {
+	if _, err := client.RemoveUser(ctx, &api.EmptyMessage{}); err != nil {
+		return newStatusError("RemoveUser", err, nil)
+	}
+	return nil
+}
+
+// ChangeUserRequisits changes login and password of the user of the connection.
+func ChangeUserRequisits(ctx context.Context, client api.GoGameClient, login, password string) error {
+	_, err := client.ChangeUserRequisits(ctx, &api.RequisitsMessage{Login: login, Password: password})
+	if err != nil {
+		return newStatusError("ChangeUserRequisits", err, nil)
+	}
+	return nil
+}
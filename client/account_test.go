@@ -0,0 +1,87 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/client"
+	"github.com/yagoggame/grpc_client/client/clienttest"
+)
+
+// newServer starts the stand-in server, it should be closed by the test.
+func newServer(t *testing.T) *clienttest.Server {
+	t.Helper()
+	s, err := clienttest.NewServer(5, 0.5)
+	if err != nil {
+		t.Fatalf("can't start the server: %v", err)
+	}
+	return s
+}
+
+// connect connects to the server as the user.
+// The connection is closed with the returned function.
+func connect(t *testing.T, s *clienttest.Server, login, password string) (api.GoGameClient, func() error) {
+	t.Helper()
+	conn, err := s.Connect(s.IniData(login, password))
+	if err != nil {
+		t.Fatalf("can't connect: %v", err)
+	}
+	return api.NewGoGameClient(conn), conn.Close
+}
+
+func TestAccountFunctions(t *testing.T) {
+	s := newServer(t)
+	defer s.Close()
+	ctx := context.Background()
+
+	c, closeConn := connect(t, s, "alice", "secret")
+	defer closeConn()
+	if err := client.RegisterUser(ctx, c); err != nil {
+		t.Fatalf("RegisterUser: %v", err)
+	}
+	if password, ok := s.Password("alice"); !ok || password != "secret" {
+		t.Fatalf("the user is not registered: %q, %t", password, ok)
+	}
+	var stErr *client.StatusError
+	if err := client.RegisterUser(ctx, c); !errors.As(err, &stErr) || stErr.Op != "RegisterUser" {
+		t.Errorf("second RegisterUser: got %v, want StatusError of RegisterUser", err)
+	}
+
+	if err := client.ChangeUserRequisits(ctx, c, "bob", "newsecret"); err != nil {
+		t.Fatalf("ChangeUserRequisits: %v", err)
+	}
+	if _, ok := s.Password("alice"); ok {
+		t.Error("the old login is still registered")
+	}
+	// the old requisits are not valid any more.
+	if err := client.RemoveUser(ctx, c); !errors.Is(err, client.ErrUnauthenticated) {
+		t.Errorf("RemoveUser with old requisits: got %v, want ErrUnauthenticated", err)
+	}
+
+	c, closeConn = connect(t, s, "bob", "newsecret")
+	defer closeConn()
+	if err := client.RemoveUser(ctx, c); err != nil {
+		t.Fatalf("RemoveUser: %v", err)
+	}
+	if _, ok := s.Password("bob"); ok {
+		t.Error("the user is not removed")
+	}
+}
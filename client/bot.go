@@ -0,0 +1,147 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+)
+
+// maxBotAttempts limits commands of the bot in the same mode,
+// e.g. turns rejected by the server.
+const maxBotAttempts = 10
+
+// bot gives commands to the game loop instead of the user.
+type bot struct {
+	engine MoveEngine
+	// games limits the number of games, 0 means no limit.
+	games  int
+	played int
+	// mode and attempts track commands given in the same mode.
+	mode     gameMode
+	attempts int
+	// err stops the bot.
+	err error
+}
+
+// act gives the command of the bot in the current mode of the game.
+// acted is false, when the bot waits for the server,
+// cont is false, when the bot quits.
+func (b *bot) act(state *gameState) (acted, cont bool) {
+	if b == nil {
+		return false, true
+	}
+	if state.currentMode != b.mode {
+		b.mode, b.attempts = state.currentMode, 0
+	}
+
+	switch state.currentMode {
+	case noGame, performTurn, gameOver:
+		b.attempts++
+		if b.attempts > maxBotAttempts {
+			b.fail(fmt.Errorf("the bot made no progress in %s mode after %d attempts", modeNames[b.mode], maxBotAttempts))
+		}
+	default:
+		return false, true
+	}
+	if b.err != nil {
+		return true, false
+	}
+
+	switch state.currentMode {
+	case noGame:
+		if b.games > 0 && b.played >= b.games {
+			return true, false
+		}
+		b.played++
+		state.joinGame()
+	case performTurn:
+		if state.gameData.GetGameOver() {
+			state.releaseGameResources()
+			break
+		}
+		b.turn(state)
+	default:
+		state.releaseGameResources()
+	}
+	return true, b.err == nil
+}
+
+// turn makes the turn chosen by the engine.
+// The game is left, if the engine has no move.
+func (b *bot) turn(state *gameState) {
+	if setter, ok := b.engine.(ColourSetter); ok {
+		colour := state.session.Colour()
+		if colour == board.Empty {
+			b.fail(errors.New("the colour of the bot's stones is unknown"))
+			return
+		}
+		setter.SetColour(colour)
+	}
+
+	x, y, err := b.engine.NextMove(state.gameData)
+	switch {
+	case errors.Is(err, ErrNoMove):
+		fmt.Fprintf(state.out, "The engine has no move: %s. Leave the game.\n", err)
+		state.emitError(err)
+		state.releaseGameResources()
+	case err != nil:
+		b.fail(fmt.Errorf("engine failed: %w", err))
+	default:
+		state.makeTurn(x, y)
+	}
+}
+
+// fail stops the bot with the error, if it is not stopped yet.
+func (b *bot) fail(err error) {
+	if b != nil && b.err == nil {
+		b.err = err
+	}
+}
+
+// error returns the error the bot is stopped with.
+func (b *bot) error() error {
+	if b == nil {
+		return nil
+	}
+	return b.err
+}
+
+// BotFlow plays games with the moves of the engine instead of the user.
+// Games are joined one after another, games limits their number, 0 means no limit.
+// Messages are written to out. The session is configured with opts.
+func BotFlow(connection api.GoGameClient, engine MoveEngine, games int, out io.Writer, quit <-chan interface{}, opts ...SessionOption) (err error) {
+	fmt.Fprintf(out, "Try to enter the Lobby...\n")
+	session, err := NewSession(context.Background(), connection, opts...)
+	if err != nil {
+		return fmt.Errorf("can't enter the lobby: %w", err)
+	}
+
+	defer func(s *Session) {
+		fmt.Fprintf(out, "Leave the Lobby...\n")
+		if closeErr := s.Close(context.Background()); closeErr != nil && err == nil {
+			err = fmt.Errorf("can't leave the lobby: %w", closeErr)
+		}
+	}(session)
+
+	return manageGame(session, nil, out, quit, &bot{engine: engine, games: games})
+}
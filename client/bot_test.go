@@ -0,0 +1,65 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+	"github.com/yagoggame/grpc_client/client"
+	"github.com/yagoggame/grpc_client/client/clienttest"
+)
+
+// colourEngine remembers colours it is asked to play.
+type colourEngine struct {
+	engine  *client.RandomEngine
+	colours []board.Colour
+}
+
+func (e *colourEngine) SetColour(colour board.Colour) {
+	e.colours = append(e.colours, colour)
+	e.engine.SetColour(colour)
+}
+
+func (e *colourEngine) NextMove(state *api.State) (x, y int, err error) {
+	return e.engine.NextMove(state)
+}
+
+func TestBotFlowColour(t *testing.T) {
+	for _, colour := range []board.Colour{board.Black, board.White} {
+		t.Run(colour.String(), func(t *testing.T) {
+			fake := clienttest.NewFake(5, 0.5)
+			fake.SetPlayerColour(colour)
+			fake.Script(board.Point{X: 1, Y: 1}, board.Point{X: 5, Y: 5}, board.Point{X: 1, Y: 5})
+			engine := &colourEngine{engine: client.NewRandomEngine(1)}
+
+			if err := client.BotFlow(fake, engine, 1, ioutil.Discard, nil); err != nil {
+				t.Fatalf("BotFlow: %v", err)
+			}
+			if len(engine.colours) == 0 || len(fake.Turns()) == 0 {
+				t.Fatalf("the bot made no turns")
+			}
+			for _, c := range engine.colours {
+				if c != colour {
+					t.Fatalf("the engine is asked to play %v, want %v", engine.colours, colour)
+				}
+			}
+		})
+	}
+}
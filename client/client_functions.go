@@ -20,15 +20,16 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/yagoggame/api"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/keepalive"
 )
 
 // IniDataContainer is a container of initial data to run server.
@@ -39,10 +40,51 @@ type IniDataContainer struct {
 	IP string
 	//CertFile is the path to certificate file of grpc service
 	CertFile string
+	//CAFiles are paths to additional bundles of CA certificates to verify grpc service
+	CAFiles []string
+	//SystemRoots makes the system pool of certificates be trusted too
+	SystemRoots bool
+	//ServerName overrides the name of grpc service to verify its certificate
+	ServerName string
+	//ClientCertFile is the path to the client certificate presented to grpc service
+	ClientCertFile string
+	//ClientKeyFile is the path to the key of the client certificate
+	ClientKeyFile string
+	//Insecure disables TLS, login and password are sent in clear
+	Insecure bool
+	//Token makes login and password be exchanged for a token sent instead of them
+	Token bool
+	//TokenCacheDir is the directory to cache tokens between runs, if not empty
+	TokenCacheDir string
 	//Login is the user's login
 	Login string
 	//Password is the user's password
 	Password string
+	//Keepalive is the period of pings to check the connection, DefaultKeepalive if zero
+	Keepalive time.Duration
+	//KeepaliveTimeout is the time to wait for the ping ack, DefaultKeepaliveTimeout if zero
+	KeepaliveTimeout time.Duration
+}
+
+const (
+	// DefaultKeepalive is the default period of pings to check the connection.
+	// It is the shortest period permitted by grpc, so a dead connection
+	// is found in DefaultKeepalive+DefaultKeepaliveTimeout at most.
+	// The server must permit pings so often with KeepaliveEnforcementPolicy,
+	// otherwise it closes the connection with "too_many_pings".
+	DefaultKeepalive = 10 * time.Second
+	// DefaultKeepaliveTimeout is the default time to wait for the ping ack,
+	// before the connection is considered dead.
+	DefaultKeepaliveTimeout = 5 * time.Second
+)
+
+// KeepaliveEnforcementPolicy is the policy for grpc_server
+// to permit pings of clients with the default keepalive.
+// Pings are sent while the turn of the opponent is awaited too,
+// so they are permitted without streams.
+var KeepaliveEnforcementPolicy = keepalive.EnforcementPolicy{
+	MinTime:             DefaultKeepalive / 2,
+	PermitWithoutStream: true,
 }
 
 // myUsage append the standart flag.Usage function with positional arguments.
@@ -55,6 +97,8 @@ func myUsage() {
 type Authentication struct {
 	Login    string
 	Password string
+	// Insecure allows to send login/password without TLS.
+	Insecure bool
 }
 
 // GetRequestMetadata gets the current request metadata.
@@ -67,35 +111,68 @@ func (a *Authentication) GetRequestMetadata(context.Context, ...string) (map[str
 
 // RequireTransportSecurity indicates whether the credentials requires transport security.
 func (a *Authentication) RequireTransportSecurity() bool {
-	return true
+	return !a.Insecure
 }
 
 // Connect performs connection to grpc server.
-func Connect(initData *IniDataContainer) (conn *grpc.ClientConn, err error) {
-	// Create the client TLS credentials.
-	creds, err := credentials.NewClientTLSFromFile(initData.CertFile, "")
-	if err != nil {
-		return nil, fmt.Errorf("could not load tls cert: %s", err)
+// The connection is secured with TLS, unless initData.Insecure is set.
+// Additional options, if any, are passed to grpc.Dial.
+func Connect(initData *IniDataContainer, opts ...grpc.DialOption) (conn *grpc.ClientConn, err error) {
+	security := grpc.WithInsecure()
+	if !initData.Insecure {
+		// Create the client TLS credentials.
+		creds, err := transportCredentials(initData)
+		if err != nil {
+			return nil, fmt.Errorf("could not load tls cert: %w", err)
+		}
+		security = grpc.WithTransportCredentials(creds)
 	}
 
-	// Setup the login/pass.
-	auth := Authentication{
+	// Setup the login/pass or the token.
+	var auth credentials.PerRPCCredentials = &Authentication{
 		Login:    initData.Login,
 		Password: initData.Password,
+		Insecure: initData.Insecure,
+	}
+	if initData.Token {
+		address := fmt.Sprintf("%s:%d", initData.IP, initData.Port)
+		var cacheFile string
+		if initData.TokenCacheDir != "" {
+			cacheFile = TokenCacheFile(initData.TokenCacheDir, initData.Login, initData.Password, address)
+		}
+		token := NewTokenAuthentication(initData.Login, initData.Password, cacheFile, initData.Insecure)
+		auth = token
+		opts = append([]grpc.DialOption{grpc.WithChainUnaryInterceptor(token.Interceptor())}, opts...)
+	}
+
+	// Ping the server to detect dead connections during long calls like WaitTheTurn.
+	// There is nothing to check without calls, servers don't permit such pings by default.
+	params := keepalive.ClientParameters{
+		Time:    initData.Keepalive,
+		Timeout: initData.KeepaliveTimeout,
+	}
+	if params.Time == 0 {
+		params.Time = DefaultKeepalive
+	}
+	if params.Timeout == 0 {
+		params.Timeout = DefaultKeepaliveTimeout
 	}
 
-	conn, err = grpc.Dial(fmt.Sprintf("%s:%d", initData.IP, initData.Port),
-		grpc.WithTransportCredentials(creds),
-		grpc.WithPerRPCCredentials(&auth))
+	opts = append([]grpc.DialOption{
+		security,
+		grpc.WithPerRPCCredentials(auth),
+		grpc.WithKeepaliveParams(params)}, opts...)
+	conn, err = grpc.Dial(fmt.Sprintf("%s:%d", initData.IP, initData.Port), opts...)
 
 	if err != nil {
-		return nil, fmt.Errorf("did not connect: %s", err)
+		return nil, fmt.Errorf("did not connect: %w", err)
 	}
 
 	return conn, err
 }
 
 // HandleSignals handles signals SIGINT, SIGTERM.
+// The signal is reported to the standard error, the standard output is left to the game.
 func HandleSignals() <-chan interface{} {
 	sigs := make(chan os.Signal, 1)
 	done := make(chan interface{})
@@ -103,35 +180,32 @@ func HandleSignals() <-chan interface{} {
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 	go func(chan<- interface{}) {
 		sig := <-sigs
-		fmt.Println()
-		fmt.Println(sig)
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, sig)
 		close(done)
 	}(done)
 
 	return done
 }
 
-// GameFlow performs main interactive procedure to interact with the game
-func GameFlow(connection api.GoGameClient, quit <-chan interface{}) {
-	fmt.Printf("Try to enter the Lobby...\n")
-	_, err := connection.EnterTheLobby(context.Background(), &api.EmptyMessage{})
+// GameFlow performs main interactive procedure to interact with the game.
+// User commands are read from in, messages are written to out.
+// The error which finished the game, e.g. ErrGameAborted, is returned,
+// if the user quits without leaving the game.
+// The session is configured with opts.
+func GameFlow(connection api.GoGameClient, in io.Reader, out io.Writer, quit <-chan interface{}, opts ...SessionOption) (err error) {
+	fmt.Fprintf(out, "Try to enter the Lobby...\n")
+	session, err := NewSession(context.Background(), connection, opts...)
 	if err != nil {
-		st := status.Convert(err)
-		log.Fatalf("status error when calling EnterTheLobby: %v: %s", st.Code(), st.Message())
+		return fmt.Errorf("can't enter the lobby: %w", err)
 	}
 
-	defer func(c api.GoGameClient) {
-		fmt.Printf("Leave the Lobby...\n")
-		_, err = c.LeaveTheLobby(context.Background(), &api.EmptyMessage{})
-		if err != nil {
-			st := status.Convert(err)
-			log.Fatalf("status error when calling LeaveTheLobby: %v: %s", st.Code(), st.Message())
+	defer func(s *Session) {
+		fmt.Fprintf(out, "Leave the Lobby...\n")
+		if closeErr := s.Close(context.Background()); closeErr != nil && err == nil {
+			err = fmt.Errorf("can't leave the lobby: %w", closeErr)
 		}
-	}(connection)
+	}(session)
 
-	err = manageGame(connection, quit)
-	if err != nil {
-		st := status.Convert(err)
-		log.Fatalf("status error when calling EnterTheLobby: %v: %s", st.Code(), st.Message())
-	}
+	return manageGame(session, in, out, quit, nil)
 }
@@ -0,0 +1,338 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package clienttest provides utilities to exercise the game flow
+// without a running grpc_server.
+package clienttest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Names of the methods of api.GoGameClient, to be used with InjectError.
+const (
+	RegisterUser        = "RegisterUser"
+	RemoveUser          = "RemoveUser"
+	ChangeUserRequisits = "ChangeUserRequisits"
+	EnterTheLobby       = "EnterTheLobby"
+	LeaveTheLobby       = "LeaveTheLobby"
+	JoinTheGame         = "JoinTheGame"
+	WaitTheTurn         = "WaitTheTurn"
+	LeaveTheGame        = "LeaveTheGame"
+	MakeTurn            = "MakeTurn"
+)
+
+// Fake is a scriptable in-memory implementation of api.GoGameClient.
+// It keeps a real board with two virtual seats: the player,
+// who makes turns with MakeTurn, and the opponent,
+// whose turns are taken from the script on every WaitTheTurn call.
+// When the script is exhausted, the game is over.
+type Fake struct {
+	mu sync.Mutex
+
+	game        *game
+	playerSeat  board.Colour
+	script      []board.Point
+	inLobby     bool
+	inGame      bool
+	waitDelay   time.Duration
+	errs        map[string][]error
+	calls       []string
+	requisits   *api.RequisitsMessage
+	registered  bool
+	turnsPlayed []board.Point
+}
+
+var _ api.GoGameClient = (*Fake)(nil)
+
+// NewFake creates a Fake with the board of given size and komi.
+// The player takes black stones.
+func NewFake(size int, komi float64) *Fake {
+	return &Fake{
+		game:       newGame(size, komi),
+		playerSeat: board.Black,
+		errs:       make(map[string][]error),
+	}
+}
+
+// SetPlayerColour sets the colour of the player's stones.
+// It should be called before JoinTheGame.
+func (f *Fake) SetPlayerColour(c board.Colour) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.playerSeat = c
+}
+
+// Script appends turns of the opponent.
+func (f *Fake) Script(turns ...board.Point) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.script = append(f.script, turns...)
+}
+
+// SetWaitDelay sets how long JoinTheGame and WaitTheTurn block before return.
+// Negative delay blocks them until the context is done.
+func (f *Fake) SetWaitDelay(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.waitDelay = d
+}
+
+// InjectError queues err to be returned by the next call of the method.
+// Several errors for the same method are returned by consecutive calls.
+func (f *Fake) InjectError(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errs[method] = append(f.errs[method], err)
+}
+
+// Calls returns names of methods called so far.
+func (f *Fake) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+// Turns returns the player's turns accepted so far.
+func (f *Fake) Turns() []board.Point {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]board.Point(nil), f.turnsPlayed...)
+}
+
+// Requisits returns the requisits passed to the last ChangeUserRequisits call.
+func (f *Fake) Requisits() *api.RequisitsMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requisits
+}
+
+// State returns the current state of the game.
+func (f *Fake) State() *api.State {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.game.state()
+}
+
+// enter registers the call and returns an injected error, if any.
+func (f *Fake) enter(method string) error {
+	f.calls = append(f.calls, method)
+	if errs := f.errs[method]; len(errs) > 0 {
+		f.errs[method] = errs[1:]
+		return errs[0]
+	}
+	return nil
+}
+
+// call is a shortcut for the calls that do not need a lock while executed.
+func (f *Fake) call(method string, fnc func() error) (*api.EmptyMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enter(method); err != nil {
+		return nil, err
+	}
+	if err := fnc(); err != nil {
+		return nil, err
+	}
+	return &api.EmptyMessage{}, nil
+}
+
+// wait blocks for the configured delay or until ctx is done.
+func (f *Fake) wait(ctx context.Context) error {
+	f.mu.Lock()
+	d := f.waitDelay
+	f.mu.Unlock()
+
+	var timer <-chan time.Time
+	if d >= 0 {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	select {
+	case <-timer:
+		return nil
+	case <-ctx.Done():
+		return contextError(ctx)
+	}
+}
+
+// RegisterUser registers the user.
+func (f *Fake) RegisterUser(ctx context.Context, in *api.EmptyMessage, opts ...grpc.CallOption) (*api.EmptyMessage, error) {
+	return f.call(RegisterUser, func() error {
+		if f.registered {
+			return status.Error(codes.AlreadyExists, "user is already registered")
+		}
+		f.registered = true
+		return nil
+	})
+}
+
+// RemoveUser removes the user.
+func (f *Fake) RemoveUser(ctx context.Context, in *api.EmptyMessage, opts ...grpc.CallOption) (*api.EmptyMessage, error) {
+	return f.call(RemoveUser, func() error {
+		f.registered = false
+		return nil
+	})
+}
+
+// ChangeUserRequisits remembers new requisits of the user.
+func (f *Fake) ChangeUserRequisits(ctx context.Context, in *api.RequisitsMessage, opts ...grpc.CallOption) (*api.EmptyMessage, error) {
+	return f.call(ChangeUserRequisits, func() error {
+		f.requisits = in
+		return nil
+	})
+}
+
+// EnterTheLobby puts the player into the lobby.
+func (f *Fake) EnterTheLobby(ctx context.Context, in *api.EmptyMessage, opts ...grpc.CallOption) (*api.EmptyMessage, error) {
+	return f.call(EnterTheLobby, func() error {
+		if f.inLobby {
+			return status.Error(codes.AlreadyExists, "already in the lobby")
+		}
+		f.inLobby = true
+		return nil
+	})
+}
+
+// LeaveTheLobby removes the player from the lobby and from the game, if any.
+func (f *Fake) LeaveTheLobby(ctx context.Context, in *api.EmptyMessage, opts ...grpc.CallOption) (*api.EmptyMessage, error) {
+	return f.call(LeaveTheLobby, func() error {
+		if !f.inLobby {
+			return status.Error(codes.FailedPrecondition, "not in the lobby")
+		}
+		f.inLobby = false
+		f.inGame = false
+		return nil
+	})
+}
+
+// JoinTheGame starts a new game with the virtual opponent.
+func (f *Fake) JoinTheGame(ctx context.Context, in *api.EmptyMessage, opts ...grpc.CallOption) (*api.State, error) {
+	f.mu.Lock()
+	err := f.enter(JoinTheGame)
+	if err == nil && !f.inLobby {
+		err = status.Error(codes.FailedPrecondition, "not in the lobby")
+	}
+	f.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.game = newGame(f.game.board.Size(), f.game.komi)
+	f.turnsPlayed = nil
+	f.inGame = true
+	return f.game.state(), nil
+}
+
+// WaitTheTurn waits for the opponent's turn taken from the script.
+func (f *Fake) WaitTheTurn(ctx context.Context, in *api.EmptyMessage, opts ...grpc.CallOption) (*api.State, error) {
+	f.mu.Lock()
+	err := f.enter(WaitTheTurn)
+	if err == nil && !f.inGame {
+		err = status.Error(codes.FailedPrecondition, "not in the game")
+	}
+	f.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.inGame {
+		return nil, status.Error(codes.Aborted, "the game is left")
+	}
+	if f.game.turn != f.playerSeat && !f.game.over {
+		f.opponentTurn()
+	}
+	return f.game.state(), nil
+}
+
+// opponentTurn plays the next legal turn from the script,
+// or finishes the game if the script is exhausted.
+func (f *Fake) opponentTurn() {
+	for len(f.script) > 0 {
+		p := f.script[0]
+		f.script = f.script[1:]
+		if err := f.game.play(f.playerSeat.Opponent(), p); err == nil {
+			return
+		}
+	}
+	f.game.over = true
+}
+
+// LeaveTheGame removes the player from the game.
+func (f *Fake) LeaveTheGame(ctx context.Context, in *api.EmptyMessage, opts ...grpc.CallOption) (*api.EmptyMessage, error) {
+	return f.call(LeaveTheGame, func() error {
+		if !f.inGame {
+			return status.Error(codes.FailedPrecondition, "not in the game")
+		}
+		f.inGame = false
+		return nil
+	})
+}
+
+// MakeTurn places the player's stone on the board.
+func (f *Fake) MakeTurn(ctx context.Context, in *api.TurnMessage, opts ...grpc.CallOption) (*api.State, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.enter(MakeTurn); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case !f.inGame:
+		return nil, status.Error(codes.FailedPrecondition, "not in the game")
+	case f.game.over:
+		return nil, status.Error(codes.FailedPrecondition, "the game is over")
+	case f.game.turn != f.playerSeat:
+		return nil, status.Error(codes.FailedPrecondition, "not your turn")
+	}
+
+	p := board.Point{X: int(in.GetX()), Y: int(in.GetY())}
+	if err := f.game.play(f.playerSeat, p); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "wrong turn %d %d: %s", p.X, p.Y, err)
+	}
+	f.turnsPlayed = append(f.turnsPlayed, p)
+	return f.game.state(), nil
+}
+
+// contextError converts the error of the done context to a status error.
+func contextError(ctx context.Context) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return status.Error(codes.DeadlineExceeded, ctx.Err().Error())
+	}
+	return status.Error(codes.Canceled, ctx.Err().Error())
+}
@@ -0,0 +1,148 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package clienttest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var empty = &api.EmptyMessage{}
+
+func TestFakeScriptedGame(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake(5, 0.5)
+	f.Script(board.Point{X: 3, Y: 3})
+
+	if _, err := f.EnterTheLobby(ctx, empty); err != nil {
+		t.Fatalf("EnterTheLobby: %v", err)
+	}
+	if _, err := f.JoinTheGame(ctx, empty); err != nil {
+		t.Fatalf("JoinTheGame: %v", err)
+	}
+	// the player takes black stones and moves first.
+	if _, err := f.WaitTheTurn(ctx, empty); err != nil {
+		t.Fatalf("WaitTheTurn: %v", err)
+	}
+	state, err := f.MakeTurn(ctx, &api.TurnMessage{X: 1, Y: 1})
+	if err != nil {
+		t.Fatalf("MakeTurn: %v", err)
+	}
+	if got := len(state.GetBlack().GetChipsOnBoard()); got != 1 {
+		t.Errorf("black stones after the turn: got %d, want 1", got)
+	}
+
+	state, err = f.WaitTheTurn(ctx, empty)
+	if err != nil {
+		t.Fatalf("WaitTheTurn: %v", err)
+	}
+	white := state.GetWhite().GetChipsOnBoard()
+	if len(white) != 1 || white[0].X != 3 || white[0].Y != 3 {
+		t.Errorf("white stones after the opponent's turn: got %v, want [3 3]", white)
+	}
+
+	// the script is exhausted, the game is over.
+	if _, err := f.MakeTurn(ctx, &api.TurnMessage{X: 2, Y: 2}); err != nil {
+		t.Fatalf("MakeTurn: %v", err)
+	}
+	if state, err = f.WaitTheTurn(ctx, empty); err != nil || !state.GetGameOver() {
+		t.Errorf("WaitTheTurn after the script: got game over %t, err %v, want game over", state.GetGameOver(), err)
+	}
+
+	if _, err := f.LeaveTheGame(ctx, empty); err != nil {
+		t.Fatalf("LeaveTheGame: %v", err)
+	}
+	if _, err := f.WaitTheTurn(ctx, empty); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("WaitTheTurn after leaving: got %v, want FailedPrecondition", err)
+	}
+	if got := f.Turns(); len(got) != 2 {
+		t.Errorf("Turns: got %v, want 2 turns", got)
+	}
+}
+
+func TestFakeRejectsWrongTurns(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake(5, 0.5)
+	f.EnterTheLobby(ctx, empty)
+	f.JoinTheGame(ctx, empty)
+
+	if _, err := f.MakeTurn(ctx, &api.TurnMessage{X: 6, Y: 1}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("turn out of the board: got %v, want InvalidArgument", err)
+	}
+	f.MakeTurn(ctx, &api.TurnMessage{X: 1, Y: 1})
+	if _, err := f.MakeTurn(ctx, &api.TurnMessage{X: 2, Y: 2}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("turn out of order: got %v, want FailedPrecondition", err)
+	}
+}
+
+func TestFakeInjectError(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake(5, 0.5)
+	f.InjectError(EnterTheLobby, status.Error(codes.Unavailable, "first"))
+	f.InjectError(EnterTheLobby, status.Error(codes.Unauthenticated, "second"))
+
+	for _, want := range []codes.Code{codes.Unavailable, codes.Unauthenticated, codes.OK} {
+		if _, err := f.EnterTheLobby(ctx, empty); status.Code(err) != want {
+			t.Errorf("EnterTheLobby: got %v, want %v", err, want)
+		}
+	}
+	want := []string{EnterTheLobby, EnterTheLobby, EnterTheLobby}
+	if got := f.Calls(); len(got) != len(want) {
+		t.Errorf("Calls: got %v, want %v", got, want)
+	}
+}
+
+func TestFakeWaitCancellation(t *testing.T) {
+	f := NewFake(5, 0.5)
+	f.EnterTheLobby(context.Background(), empty)
+	f.JoinTheGame(context.Background(), empty)
+	f.SetWaitDelay(-1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := f.WaitTheTurn(ctx, empty)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("WaitTheTurn returned before cancellation: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if status.Code(err) != codes.Canceled {
+			t.Errorf("WaitTheTurn: got %v, want Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitTheTurn is not canceled")
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := f.WaitTheTurn(ctx, empty); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("WaitTheTurn with deadline: got %v, want DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package clienttest
+
+import (
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+)
+
+// game holds the board and the counters of both colours.
+type game struct {
+	board    *board.Board
+	komi     float64
+	turn     board.Colour
+	over     bool
+	inCap    map[board.Colour]int
+	captured map[board.Colour]int
+}
+
+func newGame(size int, komi float64) *game {
+	return &game{
+		board: board.New(size),
+		komi:  komi,
+		turn:  board.Black,
+		inCap: map[board.Colour]int{
+			board.Black: (size*size + 1) / 2,
+			board.White: size * size / 2,
+		},
+		captured: make(map[board.Colour]int),
+	}
+}
+
+// play places a stone of colour c and passes the turn to the opponent.
+func (g *game) play(c board.Colour, p board.Point) error {
+	captured, err := g.board.Play(c, p)
+	if err != nil {
+		return err
+	}
+	g.inCap[c]--
+	g.captured[c] += len(captured)
+	g.turn = c.Opponent()
+	return nil
+}
+
+// state builds api.State from the game.
+func (g *game) state() *api.State {
+	return &api.State{
+		Komi:     g.komi,
+		GameOver: g.over,
+		Size:     int64(g.board.Size()),
+		Black:    g.colourState(board.Black),
+		White:    g.colourState(board.White),
+	}
+}
+
+func (g *game) colourState(c board.Colour) *api.State_ColourState {
+	scores := float64(g.captured[c])
+	if c == board.White {
+		scores += g.komi
+	}
+	cs := &api.State_ColourState{
+		ChipsInCap:    int64(g.inCap[c]),
+		ChipsCaptured: int64(g.captured[c]),
+		Scores:        scores,
+	}
+	for _, p := range g.board.Stones(c) {
+		cs.ChipsOnBoard = append(cs.ChipsOnBoard, &api.TurnMessage{X: int64(p.X), Y: int64(p.Y)})
+	}
+	return cs
+}
@@ -0,0 +1,78 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package clienttest
+
+import (
+	"net"
+	"sync"
+)
+
+// connTracker remembers accepted connections to be able to close them.
+type connTracker struct {
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+// trackingListener is a listener registering accepted connections in the tracker.
+type trackingListener struct {
+	net.Listener
+	tracker *connTracker
+}
+
+// trackedConn is a connection forgotten by the tracker when closed.
+type trackedConn struct {
+	net.Conn
+	tracker *connTracker
+}
+
+// track wraps the listener to register its connections.
+func (t *connTracker) track(l net.Listener) net.Listener {
+	return &trackingListener{Listener: l, tracker: t}
+}
+
+// closeAll closes all registered connections.
+func (t *connTracker) closeAll() {
+	t.mu.Lock()
+	conns := t.conns
+	t.conns = make(map[net.Conn]struct{})
+	t.mu.Unlock()
+
+	for c := range conns {
+		c.Close()
+	}
+}
+
+// Accept waits for the next connection and registers it.
+func (l *trackingListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	tc := &trackedConn{Conn: c, tracker: l.tracker}
+	l.tracker.mu.Lock()
+	l.tracker.conns[tc] = struct{}{}
+	l.tracker.mu.Unlock()
+	return tc, nil
+}
+
+// Close closes the connection and forgets it.
+func (c *trackedConn) Close() error {
+	c.tracker.mu.Lock()
+	delete(c.tracker.conns, c)
+	c.tracker.mu.Unlock()
+	return c.Conn.Close()
+}
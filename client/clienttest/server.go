@@ -0,0 +1,506 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package clienttest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+	"github.com/yagoggame/grpc_client/client"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	bufSize        = 1 << 20
+	registerMethod = "/api.GoGame/RegisterUser"
+)
+
+// Server is an in-process stand-in of grpc_server listening on bufconn.
+// It serves TLS, unless it is started with NewInsecureServer,
+// and checks the login and password or the token of every call,
+// so clients use the same credentials path as with a deployed server.
+// Games are played between two users who join them one after another:
+// the first one takes black stones, the second one takes white.
+type Server struct {
+	// CertFile is the path to the certificate clients should trust,
+	// it is empty if the server is insecure.
+	CertFile string
+
+	dir      string
+	listener *bufconn.Listener
+	conns    *connTracker
+	server   *grpc.Server
+	size     int
+	komi     float64
+	clientCA *x509.Certificate
+	caKey    *ecdsa.PrivateKey
+
+	mu      sync.Mutex
+	serial  int64
+	mutual  bool
+	changed chan struct{}
+	users   map[string]string
+	lobby   map[string]*player
+	waiting *player
+
+	tokens   map[string]issuedToken
+	tokenTTL time.Duration
+	issued   int
+	noTokens bool
+}
+
+// player is a user in the lobby.
+type player struct {
+	match  *match
+	colour board.Colour
+}
+
+// match is a game played by two players.
+type match struct {
+	game *game
+	left bool
+}
+
+type loginKey struct{}
+
+var _ api.GoGameServer = (*Server)(nil)
+
+// NewServer starts a Server with games on the board of given size and komi.
+func NewServer(size int, komi float64) (*Server, error) {
+	return newServer(size, komi, false)
+}
+
+// NewInsecureServer starts a Server like NewServer, but without TLS,
+// as grpc_server run for local development.
+// Clients should connect to it with IniDataContainer.Insecure set.
+func NewInsecureServer(size int, komi float64) (*Server, error) {
+	return newServer(size, komi, true)
+}
+
+func newServer(size int, komi float64, insecure bool) (*Server, error) {
+	dir, err := ioutil.TempDir("", "clienttest")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary dir: %s", err)
+	}
+
+	cert, certFile, err := selfSignedCert(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	clientCA, caKey, err := newClientCA()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	s := &Server{
+		CertFile: certFile,
+		dir:      dir,
+		listener: bufconn.Listen(bufSize),
+		conns:    &connTracker{conns: make(map[net.Conn]struct{})},
+		size:     size,
+		komi:     komi,
+		clientCA: clientCA,
+		caKey:    caKey,
+		serial:   2,
+		changed:  make(chan struct{}),
+		users:    make(map[string]string),
+		lobby:    make(map[string]*player),
+		tokens:   make(map[string]issuedToken),
+		tokenTTL: defaultTokenTTL,
+	}
+	opts := []grpc.ServerOption{
+		grpc.KeepaliveEnforcementPolicy(client.KeepaliveEnforcementPolicy),
+		grpc.UnaryInterceptor(s.authenticate),
+	}
+	if insecure {
+		s.CertFile = ""
+	} else {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(s.tlsConfig(cert))))
+	}
+	s.server = grpc.NewServer(opts...)
+	api.RegisterGoGameServer(s.server, s)
+
+	go s.server.Serve(s.conns.track(s.listener))
+	return s, nil
+}
+
+// tlsConfig returns the TLS configuration of the server with the certificate.
+// Client certificates issued with ClientCert are verified,
+// they are required after RequireClientCert.
+func (s *Server) tlsConfig(cert tls.Certificate) *tls.Config {
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(s.clientCA)
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+	}
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !s.mutual {
+			return nil, nil
+		}
+		mutual := config.Clone()
+		mutual.GetConfigForClient = nil
+		mutual.ClientAuth = tls.RequireAndVerifyClientCert
+		return mutual, nil
+	}
+	return config
+}
+
+// RequireClientCert makes the server reject connections without client certificates
+// issued with ClientCert.
+func (s *Server) RequireClientCert() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mutual = true
+}
+
+// ClientCert issues the client certificate with the name accepted by the server
+// and returns paths to the files of the certificate and its key.
+func (s *Server) ClientCert(name string) (certFile, keyFile string, err error) {
+	s.mu.Lock()
+	s.serial++
+	serial := s.serial
+	s.mu.Unlock()
+	return issueClientCert(s.dir, name, serial, s.clientCA, s.caKey)
+}
+
+// DropConnections closes all connections accepted by the server,
+// as if the network is lost. Users stay in the lobby and in their games,
+// so clients are able to reconnect and resume.
+func (s *Server) DropConnections() {
+	s.conns.closeAll()
+}
+
+// Close stops the server and removes its temporary files.
+func (s *Server) Close() {
+	s.server.Stop()
+	os.RemoveAll(s.dir)
+}
+
+// AddUser registers the user on the server.
+func (s *Server) AddUser(login, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[login] = password
+}
+
+// Password returns the password of the user and reports whether the user is registered.
+func (s *Server) Password(login string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	password, ok := s.users[login]
+	return password, ok
+}
+
+// IniData returns initial data to connect to the server as the user.
+func (s *Server) IniData(login, password string) *client.IniDataContainer {
+	return &client.IniDataContainer{
+		Port:     7777,
+		IP:       serverHost,
+		CertFile: s.CertFile,
+		Insecure: s.CertFile == "",
+		Login:    login,
+		Password: password,
+	}
+}
+
+// DialOption returns the option to make grpc.Dial reach the server.
+func (s *Server) DialOption() grpc.DialOption {
+	return grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return s.listener.Dial()
+	})
+}
+
+// Connect performs client.Connect to the server.
+// It has the signature of client.Connect to be used instead of it.
+func (s *Server) Connect(initData *client.IniDataContainer, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	return client.Connect(initData, append([]grpc.DialOption{s.DialOption()}, opts...)...)
+}
+
+// authenticate checks the token or login and password of all calls except RegisterUser.
+// The token is issued to the user authenticated with login and password on request.
+func (s *Server) authenticate(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	login, err := s.caller(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	if info.FullMethod != registerMethod {
+		if err := s.issueToken(ctx, login); err != nil {
+			return nil, err
+		}
+	}
+	return handler(context.WithValue(ctx, loginKey{}, login), req)
+}
+
+// requisits extracts login and password from the incoming metadata.
+func requisits(ctx context.Context) (login, password string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md.Get("login"); len(v) > 0 {
+		login = v[0]
+	}
+	if v := md.Get("password"); len(v) > 0 {
+		password = v[0]
+	}
+	return login, password
+}
+
+// notify wakes up all waiting calls. s.mu should be held.
+func (s *Server) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// waitFor waits until cond is true or ctx is done.
+// s.mu should be held, it is held on return too.
+func (s *Server) waitFor(ctx context.Context, cond func() bool) error {
+	for !cond() {
+		changed := s.changed
+		s.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			s.mu.Lock()
+			return contextError(ctx)
+		}
+		s.mu.Lock()
+	}
+	return nil
+}
+
+// player returns the lobby record of the caller. s.mu should be held.
+func (s *Server) player(ctx context.Context) (*player, error) {
+	login, _ := ctx.Value(loginKey{}).(string)
+	p, ok := s.lobby[login]
+	if !ok {
+		return nil, status.Error(codes.FailedPrecondition, "not in the lobby")
+	}
+	return p, nil
+}
+
+// leaveMatch removes the player from its game, if any. s.mu should be held.
+func (s *Server) leaveMatch(p *player) {
+	if s.waiting == p {
+		s.waiting = nil
+	}
+	if p.match != nil {
+		p.match.left = true
+		p.match = nil
+		s.notify()
+	}
+}
+
+// RegisterUser registers the caller with the login and password from metadata.
+func (s *Server) RegisterUser(ctx context.Context, in *api.EmptyMessage) (*api.EmptyMessage, error) {
+	login, password := requisits(ctx)
+	if login == "" || password == "" {
+		return nil, status.Error(codes.InvalidArgument, "login and password should be specified")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[login]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "user %q is already registered", login)
+	}
+	s.users[login] = password
+	return &api.EmptyMessage{}, nil
+}
+
+// RemoveUser removes the caller from the server.
+func (s *Server) RemoveUser(ctx context.Context, in *api.EmptyMessage) (*api.EmptyMessage, error) {
+	login, _ := ctx.Value(loginKey{}).(string)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.lobby[login]; ok {
+		s.leaveMatch(p)
+		delete(s.lobby, login)
+	}
+	delete(s.users, login)
+	s.dropTokens(login)
+	return &api.EmptyMessage{}, nil
+}
+
+// ChangeUserRequisits changes login and password of the caller.
+func (s *Server) ChangeUserRequisits(ctx context.Context, in *api.RequisitsMessage) (*api.EmptyMessage, error) {
+	login, _ := ctx.Value(loginKey{}).(string)
+	if in.GetLogin() == "" || in.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "login and password should be specified")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.lobby[login]; ok {
+		return nil, status.Error(codes.FailedPrecondition, "user is in the lobby")
+	}
+	if _, ok := s.users[in.GetLogin()]; ok && in.GetLogin() != login {
+		return nil, status.Errorf(codes.AlreadyExists, "user %q is already registered", in.GetLogin())
+	}
+	delete(s.users, login)
+	s.dropTokens(login)
+	s.users[in.GetLogin()] = in.GetPassword()
+	return &api.EmptyMessage{}, nil
+}
+
+// EnterTheLobby puts the caller into the lobby.
+func (s *Server) EnterTheLobby(ctx context.Context, in *api.EmptyMessage) (*api.EmptyMessage, error) {
+	login, _ := ctx.Value(loginKey{}).(string)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.lobby[login]; ok {
+		return nil, status.Error(codes.AlreadyExists, "already in the lobby")
+	}
+	s.lobby[login] = &player{}
+	return &api.EmptyMessage{}, nil
+}
+
+// LeaveTheLobby removes the caller from the lobby and from the game, if any.
+func (s *Server) LeaveTheLobby(ctx context.Context, in *api.EmptyMessage) (*api.EmptyMessage, error) {
+	login, _ := ctx.Value(loginKey{}).(string)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, err := s.player(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.leaveMatch(p)
+	delete(s.lobby, login)
+	return &api.EmptyMessage{}, nil
+}
+
+// JoinTheGame waits for another player and starts a game with them.
+func (s *Server) JoinTheGame(ctx context.Context, in *api.EmptyMessage) (*api.State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, err := s.player(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if p.match != nil {
+		return nil, status.Error(codes.AlreadyExists, "already in the game")
+	}
+
+	if s.waiting != nil && s.waiting != p {
+		m := &match{game: newGame(s.size, s.komi)}
+		s.waiting.match, s.waiting.colour = m, board.Black
+		p.match, p.colour = m, board.White
+		s.waiting = nil
+		s.notify()
+		return m.game.state(), nil
+	}
+
+	s.waiting = p
+	if err := s.waitFor(ctx, func() bool { return p.match != nil }); err != nil {
+		if s.waiting == p {
+			s.waiting = nil
+		}
+		return nil, err
+	}
+	return p.match.game.state(), nil
+}
+
+// WaitTheTurn waits for the opponent's turn.
+func (s *Server) WaitTheTurn(ctx context.Context, in *api.EmptyMessage) (*api.State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, err := s.player(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m := p.match
+	if m == nil {
+		return nil, status.Error(codes.FailedPrecondition, "not in the game")
+	}
+
+	err = s.waitFor(ctx, func() bool {
+		return m.left || m.game.over || m.game.turn == p.colour
+	})
+	switch {
+	case err != nil:
+		return nil, err
+	case m.left && !m.game.over:
+		return nil, status.Error(codes.Aborted, "the game is left")
+	}
+	return m.game.state(), nil
+}
+
+// LeaveTheGame removes the caller from the game.
+func (s *Server) LeaveTheGame(ctx context.Context, in *api.EmptyMessage) (*api.EmptyMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, err := s.player(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if p.match == nil {
+		return nil, status.Error(codes.FailedPrecondition, "not in the game")
+	}
+	s.leaveMatch(p)
+	return &api.EmptyMessage{}, nil
+}
+
+// MakeTurn places the caller's stone on the board.
+func (s *Server) MakeTurn(ctx context.Context, in *api.TurnMessage) (*api.State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, err := s.player(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m := p.match
+	switch {
+	case m == nil:
+		return nil, status.Error(codes.FailedPrecondition, "not in the game")
+	case m.left:
+		return nil, status.Error(codes.Aborted, "the game is left")
+	case m.game.over:
+		return nil, status.Error(codes.FailedPrecondition, "the game is over")
+	case m.game.turn != p.colour:
+		return nil, status.Error(codes.FailedPrecondition, "not your turn")
+	}
+
+	pt := board.Point{X: int(in.GetX()), Y: int(in.GetY())}
+	if err := m.game.play(p.colour, pt); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "wrong turn %d %d: %s", pt.X, pt.Y, err)
+	}
+	if m.game.inCap[board.Black] == 0 && m.game.inCap[board.White] == 0 {
+		m.game.over = true
+	}
+	s.notify()
+	return m.game.state(), nil
+}
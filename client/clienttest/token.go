@@ -0,0 +1,139 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package clienttest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/yagoggame/grpc_client/client"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// defaultTokenTTL is the lifetime of tokens, unless changed with SetTokenTTL.
+const defaultTokenTTL = time.Hour
+
+// issuedToken is the token issued to the user.
+type issuedToken struct {
+	login  string
+	expiry time.Time
+}
+
+// SetTokenTTL sets the lifetime of tokens issued after the call.
+func (s *Server) SetTokenTTL(ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenTTL = ttl
+}
+
+// DisableTokens makes the server ignore requests of tokens,
+// as a server without their support.
+func (s *Server) DisableTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.noTokens = true
+}
+
+// TokensIssued returns the number of tokens issued so far.
+func (s *Server) TokensIssued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.issued
+}
+
+// RevokeTokens makes all issued tokens invalid.
+func (s *Server) RevokeTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = make(map[string]issuedToken)
+}
+
+// caller returns the login of the caller authenticated with the token,
+// or with the login and password for all calls except RegisterUser.
+func (s *Server) caller(ctx context.Context, method string) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md.Get(client.AuthorizationMD); len(v) > 0 {
+		token := strings.TrimPrefix(v[0], "Bearer ")
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		issued, ok := s.tokens[token]
+		if !ok || time.Now().After(issued.expiry) {
+			delete(s.tokens, token)
+			return "", status.Error(codes.Unauthenticated, "invalid token")
+		}
+		return issued.login, nil
+	}
+
+	login, password := requisits(ctx)
+	if method != registerMethod {
+		s.mu.Lock()
+		known, ok := s.users[login]
+		s.mu.Unlock()
+		if !ok || known != password {
+			return "", status.Error(codes.Unauthenticated, "wrong login or password")
+		}
+	}
+	return login, nil
+}
+
+// issueToken issues the token to the user authenticated with the login and password,
+// if it is requested, and sends it in the header of the response.
+func (s *Server) issueToken(ctx context.Context, login string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if len(md.Get(client.TokenRequestMD)) == 0 || len(md.Get(client.AuthorizationMD)) > 0 {
+		return nil
+	}
+	s.mu.Lock()
+	noTokens := s.noTokens
+	s.mu.Unlock()
+	if noTokens {
+		return nil
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return status.Errorf(codes.Internal, "failed to generate token: %s", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	expiry := time.Now().Add(s.tokenTTL)
+	s.tokens[token] = issuedToken{login: login, expiry: expiry}
+	s.issued++
+	s.mu.Unlock()
+
+	header := metadata.Pairs(client.TokenMD, token, client.TokenExpiryMD, expiry.Format(time.RFC3339))
+	if err := grpc.SetHeader(ctx, header); err != nil {
+		return status.Errorf(codes.Internal, "failed to send token: %s", err)
+	}
+	return nil
+}
+
+// dropTokens makes tokens of the user invalid. s.mu should be held.
+func (s *Server) dropTokens(login string) {
+	for token, issued := range s.tokens {
+		if issued.login == login {
+			delete(s.tokens, token)
+		}
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
 )
 
 type positionState int
@@ -29,40 +30,84 @@ const (
 	empty positionState = iota
 	blackChip
 	whiteChip
+	lastBlackChip
+	lastWhiteChip
 )
 
 var symbols = map[positionState]string{
-	empty:     "┼",
-	blackChip: "●",
-	whiteChip: "○",
+	empty:         "┼",
+	blackChip:     "●",
+	whiteChip:     "○",
+	lastBlackChip: "◆",
+	lastWhiteChip: "◇",
 }
 
-func stringFromGameData(state *api.State) string {
+// turnMarks are the changes of the board made by the last turn.
+type turnMarks struct {
+	// lastMoves are stones placed by the opponent.
+	lastMoves []board.Point
+	// captured are intersections whose stones were captured.
+	captured []board.Point
+}
+
+// newTurnMarks compares the state with the previous one.
+// Placed stones are marked if markPlaced is set,
+// e.g. when the state is obtained by waiting of the opponent's turn.
+func newTurnMarks(prev, state *api.State, markPlaced bool) turnMarks {
+	if prev == nil || state == nil || prev.GetSize() != state.GetSize() {
+		return turnMarks{}
+	}
+	placed, captured := board.FromState(state).Diff(board.FromState(prev))
+	marks := turnMarks{captured: captured}
+	if markPlaced {
+		marks.lastMoves = placed
+	}
+	return marks
+}
+
+func stringFromGameData(state *api.State, marks turnMarks) string {
 	size := int(state.GetSize())
 	desk := make([][]positionState, size)
 	for i := range desk {
 		desk[i] = make([]positionState, size)
 	}
 	fillMarkers(desk, state)
+	fillLastMoves(desk, marks.lastMoves)
 
-	line := ""
+	line := columnLabels(size)
 	for y := range desk {
-		line += strings.Repeat(" │ ", size) + "\n"
-		subline := ""
+		line += "   " + strings.Repeat(" │ ", size) + "\n"
+		subline := fmt.Sprintf("%2d ", board.RowLabel(y+1, size))
 
 		for _, ps := range desk[y] {
 			subline += "─" + symbols[ps] + "─"
 		}
-		line += subline + "\n"
+		line += subline + fmt.Sprintf(" %d\n", board.RowLabel(y+1, size))
 	}
 
-	line += strings.Repeat(" │ ", size) + "\n"
+	line += "   " + strings.Repeat(" │ ", size) + "\n"
+	line += columnLabels(size)
+	if len(marks.lastMoves) > 0 {
+		line += fmt.Sprintf("Last move (◆/◇): %s\n", pointsNotation(marks.lastMoves, size))
+	}
+	if len(marks.captured) > 0 {
+		line += fmt.Sprintf("Captured: %s\n", pointsNotation(marks.captured, size))
+	}
 	line += fmt.Sprintf("komi is %f\n", state.GetKomi())
 	line += fmt.Sprintf("Black situation: %s\n", describeGamerSituation(state.GetBlack()))
 	line += fmt.Sprintf("White situation: %s\n", describeGamerSituation(state.GetWhite()))
 	return line
 }
 
+// columnLabels returns the line with letters of the columns.
+func columnLabels(size int) string {
+	line := "   "
+	for x := 1; x <= size; x++ {
+		line += " " + board.ColumnLabel(x) + " "
+	}
+	return line + "\n"
+}
+
 func describeGamerSituation(colourSituation *api.State_ColourState) string {
 	rez := fmt.Sprintf("Chips in cup: %3d ", colourSituation.GetChipsInCap())
 	rez += fmt.Sprintf("Chips cuptured: %3d ", colourSituation.GetChipsCaptured())
@@ -70,11 +115,43 @@ func describeGamerSituation(colourSituation *api.State_ColourState) string {
 	return rez
 }
 
+// fillMarkers puts stones of the state on the desk.
+// Stones outside the desk are skipped.
 func fillMarkers(desk [][]positionState, state *api.State) {
 	for _, p := range state.GetBlack().GetChipsOnBoard() {
-		desk[p.Y-1][p.X-1] = blackChip
+		putMarker(desk, p, blackChip)
 	}
 	for _, p := range state.GetWhite().GetChipsOnBoard() {
-		desk[p.Y-1][p.X-1] = whiteChip
+		putMarker(desk, p, whiteChip)
+	}
+}
+
+// putMarker puts the marker at the point if it is on the desk.
+func putMarker(desk [][]positionState, p *api.TurnMessage, marker positionState) {
+	size := int64(len(desk))
+	if p.GetX() < 1 || p.GetY() < 1 || p.GetX() > size || p.GetY() > size {
+		return
+	}
+	desk[p.Y-1][p.X-1] = marker
+}
+
+// fillLastMoves marks stones placed by the last turn.
+func fillLastMoves(desk [][]positionState, lastMoves []board.Point) {
+	for _, p := range lastMoves {
+		switch desk[p.Y-1][p.X-1] {
+		case blackChip:
+			desk[p.Y-1][p.X-1] = lastBlackChip
+		case whiteChip:
+			desk[p.Y-1][p.X-1] = lastWhiteChip
+		}
+	}
+}
+
+// pointsNotation returns the points in the standard notation separated by spaces.
+func pointsNotation(points []board.Point, size int) string {
+	notations := make([]string, len(points))
+	for i, p := range points {
+		notations[i] = p.Notation(size)
 	}
+	return strings.Join(notations, " ")
 }
@@ -0,0 +1,134 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+)
+
+// White plays A2 and captures the black stone at A3.
+var (
+	prevState = &api.State{
+		Size:  3,
+		Komi:  0.5,
+		Black: &api.State_ColourState{ChipsInCap: 3, ChipsOnBoard: []*api.TurnMessage{{X: 1, Y: 1}, {X: 3, Y: 3}}},
+		White: &api.State_ColourState{ChipsInCap: 4, ChipsOnBoard: []*api.TurnMessage{{X: 2, Y: 1}}},
+	}
+	captureState = &api.State{
+		Size:  3,
+		Komi:  0.5,
+		Black: &api.State_ColourState{ChipsInCap: 3, ChipsOnBoard: []*api.TurnMessage{{X: 3, Y: 3}}},
+		White: &api.State_ColourState{ChipsInCap: 3, ChipsCaptured: 1, Scores: 1.5, ChipsOnBoard: []*api.TurnMessage{{X: 2, Y: 1}, {X: 1, Y: 2}}},
+	}
+)
+
+func TestNewTurnMarks(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		prev, state *api.State
+		markPlaced  bool
+		want        turnMarks
+	}{
+		{
+			name: "opponent's turn", prev: prevState, state: captureState, markPlaced: true,
+			want: turnMarks{lastMoves: []board.Point{{X: 1, Y: 2}}, captured: []board.Point{{X: 1, Y: 1}}},
+		},
+		{
+			name: "own turn", prev: prevState, state: captureState,
+			want: turnMarks{captured: []board.Point{{X: 1, Y: 1}}},
+		},
+		{name: "no previous state", state: captureState, markPlaced: true},
+		{name: "no state", prev: prevState, markPlaced: true},
+		{name: "new board", prev: &api.State{Size: 5}, state: captureState, markPlaced: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := newTurnMarks(tc.prev, tc.state, tc.markPlaced); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDrawTurnMarks(t *testing.T) {
+	want := strings.Join([]string{
+		"    A  B  C ",
+		"    │  │  │ ",
+		" 3 ─┼──○──┼─ 3",
+		"    │  │  │ ",
+		" 2 ─◇──┼──┼─ 2",
+		"    │  │  │ ",
+		" 1 ─┼──┼──●─ 1",
+		"    │  │  │ ",
+		"    A  B  C ",
+		"Last move (◆/◇): A2",
+		"Captured: A3",
+		"komi is 0.500000",
+		"Black situation: Chips in cup:   3 Chips cuptured:   0 Chips cuptured:   0.0",
+		"White situation: Chips in cup:   3 Chips cuptured:   1 Chips cuptured:   1.5",
+		"",
+	}, "\n")
+	got := stringFromGameData(captureState, newTurnMarks(prevState, captureState, true))
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// after the user's own turn only captured stones are listed.
+	got = stringFromGameData(captureState, newTurnMarks(prevState, captureState, false))
+	if strings.Contains(got, "◇") || strings.Contains(got, "Last move") || !strings.Contains(got, "Captured: A3\n") {
+		t.Errorf("own turn is marked:\n%s", got)
+	}
+}
+
+func TestFillLastMoves(t *testing.T) {
+	desk := [][]positionState{
+		{blackChip, whiteChip},
+		{empty, blackChip},
+	}
+	fillLastMoves(desk, []board.Point{{X: 1, Y: 1}, {X: 2, Y: 1}, {X: 1, Y: 2}})
+	want := [][]positionState{
+		{lastBlackChip, lastWhiteChip},
+		// free intersections are not marked.
+		{empty, blackChip},
+	}
+	if !reflect.DeepEqual(desk, want) {
+		t.Errorf("got %v, want %v", desk, want)
+	}
+}
+
+func TestDrawStateSkipsStonesOutOfBoard(t *testing.T) {
+	state := &api.State{
+		Size: 3,
+		Black: &api.State_ColourState{ChipsOnBoard: []*api.TurnMessage{
+			{X: 1, Y: 1}, {X: 4, Y: 1}, {X: 0, Y: 2},
+		}},
+		White: &api.State_ColourState{ChipsOnBoard: []*api.TurnMessage{
+			{X: 2, Y: 2}, {X: 1, Y: -5}, {X: 100, Y: 100},
+		}},
+	}
+	drawing := DrawState(nil, state)
+	if n := strings.Count(drawing, "●"); n != 1 {
+		t.Errorf("got %d black stones, want 1:\n%s", n, drawing)
+	}
+	if n := strings.Count(drawing, "○"); n != 1 {
+		t.Errorf("got %d white stones, want 1:\n%s", n, drawing)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+)
+
+// ErrNoMove is returned by engines which can't or won't make a turn,
+// e.g. when there is no legal move. The bot leaves such a game.
+var ErrNoMove = errors.New("no move")
+
+// MoveEngine chooses turns of the bot.
+type MoveEngine interface {
+	// NextMove returns the point to play, when it is the bot's turn in the state.
+	// Coordinates are 1-based, as in api.TurnMessage.
+	NextMove(state *api.State) (x, y int, err error)
+}
+
+// ColourSetter is implemented by engines, which need the colour of the bot's stones.
+// The bot calls SetColour with the colour in the current game before NextMove.
+type ColourSetter interface {
+	SetColour(colour board.Colour)
+}
+
+// RandomEngine plays random legal moves.
+type RandomEngine struct {
+	rand   *rand.Rand
+	colour board.Colour
+}
+
+// NewRandomEngine returns the RandomEngine with the source of moves initialised with seed.
+func NewRandomEngine(seed int64) *RandomEngine {
+	return &RandomEngine{rand: rand.New(rand.NewSource(seed))}
+}
+
+// SetColour sets the colour of stones to play.
+func (e *RandomEngine) SetColour(colour board.Colour) {
+	e.colour = colour
+}
+
+// NextMove returns a random free point, where the stone is not a suicide.
+// Ko is not known from the state, such moves are rejected by the server
+// and the bot asks for another one.
+func (e *RandomEngine) NextMove(state *api.State) (x, y int, err error) {
+	if e.colour == board.Empty {
+		return 0, 0, errors.New("the colour of stones is not set")
+	}
+	b := board.FromState(state)
+	var legal []board.Point
+	for y := 1; y <= b.Size(); y++ {
+		for x := 1; x <= b.Size(); x++ {
+			p := board.Point{X: x, Y: y}
+			if b.Check(e.colour, p) == nil {
+				legal = append(legal, p)
+			}
+		}
+	}
+	if len(legal) == 0 {
+		return 0, 0, ErrNoMove
+	}
+	p := legal[e.rand.Intn(len(legal))]
+	return p.X, p.Y, nil
+}
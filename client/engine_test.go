@@ -0,0 +1,93 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+	"github.com/yagoggame/grpc_client/client"
+)
+
+// stateOf returns the state of the game on the board.
+func stateOf(b *board.Board) *api.State {
+	chips := func(c board.Colour) []*api.TurnMessage {
+		var rez []*api.TurnMessage
+		for _, p := range b.Stones(c) {
+			rez = append(rez, &api.TurnMessage{X: int64(p.X), Y: int64(p.Y)})
+		}
+		return rez
+	}
+	return &api.State{
+		Size:  int64(b.Size()),
+		Komi:  0.5,
+		Black: &api.State_ColourState{ChipsOnBoard: chips(board.Black)},
+		White: &api.State_ColourState{ChipsOnBoard: chips(board.White)},
+	}
+}
+
+func TestRandomEngineLegalMoves(t *testing.T) {
+	// white stones at 2 1, 1 2 and 3 2 make 1 1 and 3 1 suicides for black,
+	// 3 3 is occupied.
+	b := board.New(3)
+	for _, p := range []board.Point{{X: 2, Y: 1}, {X: 1, Y: 2}, {X: 3, Y: 2}} {
+		b.Put(board.White, p)
+	}
+	b.Put(board.Black, board.Point{X: 3, Y: 3})
+	legal := map[board.Point]bool{{X: 2, Y: 2}: true, {X: 1, Y: 3}: true, {X: 2, Y: 3}: true}
+	state := stateOf(b)
+
+	engine := client.NewRandomEngine(1)
+	engine.SetColour(board.Black)
+	seen := make(map[board.Point]bool)
+	for i := 0; i < 100; i++ {
+		x, y, err := engine.NextMove(state)
+		if err != nil {
+			t.Fatalf("NextMove: %v", err)
+		}
+		p := board.Point{X: x, Y: y}
+		if !legal[p] {
+			t.Fatalf("NextMove: got %d %d, want one of %v", x, y, legal)
+		}
+		seen[p] = true
+	}
+	if len(seen) != len(legal) {
+		t.Errorf("not all legal moves are played: %v", seen)
+	}
+}
+
+func TestRandomEngineNoMove(t *testing.T) {
+	b := board.New(2)
+	b.Put(board.White, board.Point{X: 1, Y: 1})
+	b.Put(board.White, board.Point{X: 2, Y: 2})
+	b.Put(board.Black, board.Point{X: 1, Y: 2})
+	b.Put(board.Black, board.Point{X: 2, Y: 1})
+
+	engine := client.NewRandomEngine(1)
+	engine.SetColour(board.Black)
+	if x, y, err := engine.NextMove(stateOf(b)); !errors.Is(err, client.ErrNoMove) {
+		t.Errorf("full board: got %d %d %v, want ErrNoMove", x, y, err)
+	}
+}
+
+func TestRandomEngineNoColour(t *testing.T) {
+	if _, _, err := client.NewRandomEngine(1).NextMove(stateOf(board.New(3))); err == nil {
+		t.Error("NextMove without colour: error expected")
+	}
+}
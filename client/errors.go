@@ -0,0 +1,94 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// ErrNotInLobby is returned when an action requires to be in the lobby.
+	ErrNotInLobby = errors.New("not in the lobby")
+	// ErrNotInGame is returned when an action requires to be in a game.
+	ErrNotInGame = errors.New("not in the game")
+	// ErrAlreadyInGame is returned when joining a game while playing another one.
+	ErrAlreadyInGame = errors.New("already in the game")
+	// ErrInvalidTurn is returned when the server rejects a turn.
+	ErrInvalidTurn = errors.New("invalid turn")
+	// ErrGameAborted is returned when the game is aborted, e.g. left by the opponent.
+	ErrGameAborted = errors.New("game aborted")
+	// ErrUnauthenticated is returned when the server rejects login or password.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrUnavailable is returned when the server can't be reached.
+	ErrUnavailable = errors.New("server unavailable")
+	// ErrDeadlineExceeded is returned when the call is not finished in time.
+	ErrDeadlineExceeded = errors.New("deadline exceeded")
+)
+
+// commonKinds maps status codes to the kinds of errors for all calls.
+var commonKinds = map[codes.Code]error{
+	codes.Unauthenticated:  ErrUnauthenticated,
+	codes.Unavailable:      ErrUnavailable,
+	codes.DeadlineExceeded: ErrDeadlineExceeded,
+}
+
+// StatusError is an error returned by the grpc_server.
+type StatusError struct {
+	// Op is the name of the failed call.
+	Op string
+	// Code is the status code of the call.
+	Code codes.Code
+	// Message is the status message of the call.
+	Message string
+	// Err is the kind of the error, if known.
+	Err error
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status error when calling %s: %v: %s", e.Op, e.Code, e.Message)
+}
+
+// Unwrap returns the kind of the error.
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// GRPCStatus returns the status, so status.Convert keeps the code.
+func (e *StatusError) GRPCStatus() *status.Status {
+	return status.New(e.Code, e.Message)
+}
+
+// newStatusError wraps the error of the call op.
+// kinds maps status codes to the kinds of errors specific for this call,
+// they take precedence over commonKinds.
+func newStatusError(op string, err error, kinds map[codes.Code]error) error {
+	st := status.Convert(err)
+	kind, ok := kinds[st.Code()]
+	if !ok {
+		kind = commonKinds[st.Code()]
+	}
+	return &StatusError{
+		Op:      op,
+		Code:    st.Code(),
+		Message: st.Message(),
+		Err:     kind,
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+	"google.golang.org/grpc/status"
+)
+
+// Types of events.
+const (
+	// EventConnection reports the connection to the server or the change of its state.
+	EventConnection = "connection"
+	// EventReconnecting reports an attempt to restore the lost connection.
+	EventReconnecting = "reconnecting"
+	// EventLobby reports that the user entered the lobby.
+	EventLobby = "lobby"
+	// EventJoined reports the start of the game.
+	EventJoined = "joined"
+	// EventState reports the state of the game obtained from the server.
+	EventState = "state"
+	// EventLeft reports that the user left the game.
+	EventLeft = "left"
+	// EventPrompt reports that the commands of the mode are awaited.
+	EventPrompt = "prompt"
+	// EventDone reports the successful call of the account function.
+	EventDone = "done"
+	// EventDryRun reports the action which would be performed.
+	EventDryRun = "dry-run"
+	// EventError reports an error.
+	EventError = "error"
+)
+
+// Event is a message to scripts wrapping the client.
+// Fields not related to the type of the event are omitted.
+type Event struct {
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+	// Op is the name of the call to the server.
+	Op         string     `json:"op,omitempty"`
+	Login      string     `json:"login,omitempty"`
+	Address    string     `json:"address,omitempty"`
+	Connection string     `json:"connection,omitempty"`
+	Attempt    int        `json:"attempt,omitempty"`
+	Mode       string     `json:"mode,omitempty"`
+	State      *GameState `json:"state,omitempty"`
+	Error      *ErrorInfo `json:"error,omitempty"`
+	Message    string     `json:"message,omitempty"`
+	// Line is the line of the input file the event is related to.
+	Line int `json:"line,omitempty"`
+}
+
+// GameState is the state of the game in events.
+type GameState struct {
+	Size     int64   `json:"size"`
+	Komi     float64 `json:"komi"`
+	GameOver bool    `json:"game_over"`
+	// Board has rows from the first one, "B" and "W" are stones, "." is a free intersection.
+	Board []string        `json:"board"`
+	Black ColourSituation `json:"black"`
+	White ColourSituation `json:"white"`
+}
+
+// ColourSituation is the situation of the player of a colour.
+type ColourSituation struct {
+	ChipsOnBoard  int     `json:"chips_on_board"`
+	ChipsInCap    int64   `json:"chips_in_cap"`
+	ChipsCaptured int64   `json:"chips_captured"`
+	Scores        float64 `json:"scores"`
+}
+
+// ErrorInfo describes an error in events.
+type ErrorInfo struct {
+	Op string `json:"op,omitempty"`
+	// Code is the gRPC status code, "Unknown" for errors not returned by the server.
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewGameState converts the state obtained from the server.
+func NewGameState(state *api.State) *GameState {
+	if state == nil {
+		return nil
+	}
+	b := board.FromState(state)
+	rows := make([]string, b.Size())
+	for y := range rows {
+		var row strings.Builder
+		for x := 1; x <= b.Size(); x++ {
+			switch b.At(board.Point{X: x, Y: y + 1}) {
+			case board.Black:
+				row.WriteByte('B')
+			case board.White:
+				row.WriteByte('W')
+			default:
+				row.WriteByte('.')
+			}
+		}
+		rows[y] = row.String()
+	}
+	return &GameState{
+		Size:     state.GetSize(),
+		Komi:     state.GetKomi(),
+		GameOver: state.GetGameOver(),
+		Board:    rows,
+		Black:    newColourSituation(state.GetBlack()),
+		White:    newColourSituation(state.GetWhite()),
+	}
+}
+
+func newColourSituation(colourState *api.State_ColourState) ColourSituation {
+	return ColourSituation{
+		ChipsOnBoard:  len(colourState.GetChipsOnBoard()),
+		ChipsInCap:    colourState.GetChipsInCap(),
+		ChipsCaptured: colourState.GetChipsCaptured(),
+		Scores:        colourState.GetScores(),
+	}
+}
+
+// NewErrorInfo describes the error, StatusError keeps the name of the call.
+func NewErrorInfo(err error) *ErrorInfo {
+	info := &ErrorInfo{
+		Code:    status.Code(err).String(),
+		Message: err.Error(),
+	}
+	var stErr *StatusError
+	if errors.As(err, &stErr) {
+		info.Op = stErr.Op
+		info.Code = stErr.Code.String()
+	}
+	return info
+}
+
+// EventWriter writes events as lines of JSON.
+// It is safe to use from several goroutines.
+type EventWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewEventWriter returns the EventWriter writing to w.
+func NewEventWriter(w io.Writer) *EventWriter {
+	return &EventWriter{enc: json.NewEncoder(w)}
+}
+
+// Emit writes the event, the time is set if it is zero.
+func (w *EventWriter) Emit(ev Event) error {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.enc.Encode(ev)
+}
+
+// EmitError writes the error event.
+func (w *EventWriter) EmitError(err error) error {
+	return w.Emit(Event{Event: EventError, Error: NewErrorInfo(err)})
+}
+
+// WithEvents makes the game report events to w instead of the text for the user.
+func WithEvents(w *EventWriter) SessionOption {
+	return func(s *Session) {
+		s.events = w
+	}
+}
+
+// Events returns the writer of events, or nil if the text is shown to the user.
+func (s *Session) Events() *EventWriter {
+	return s.events
+}
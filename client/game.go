@@ -19,13 +19,15 @@ package client
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
-	"os"
+	"io"
+	"io/ioutil"
 
 	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
 	"github.com/yagoggame/grpc_client/terminal"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/connectivity"
 )
 
 type gameMode int
@@ -43,9 +45,20 @@ const (
 	gameOver
 )
 
+// modeNames are names of modes in events.
+var modeNames = map[gameMode]string{
+	noGame:      "lobby",
+	waitJoin:    "wait-join",
+	waitTurn:    "wait-turn",
+	performTurn: "turn",
+	gameOver:    "game-over",
+}
+
 // gameState is type to hold current state of the game.
 type gameState struct {
-	client api.GoGameClient
+	session *Session
+	//output of the messages for the user
+	out io.Writer
 	//currentMode
 	currentMode gameMode
 	//chanel to await for server continious actions
@@ -56,20 +69,42 @@ type gameState struct {
 	msg string
 	//state of game obtained from server
 	gameData *api.State
+	//changes of the board made by the last turn
+	marks turnMarks
+	//state of the connection shown to the user
+	connState connectivity.State
+	//bot playing instead of the user, if any
+	bot *bot
+	//error which finished the current game, returned if the user quits without leaving it
+	gameErr error
 }
 
 func (state *gameState) processUserCommands(cmdLines <-chan string, quit <-chan interface{}) {
 	process := true
 	for process == true {
+		// the bot gives commands without waiting for the user.
+		if acted, cont := state.bot.act(state); acted {
+			process = cont
+			state.printInvitation()
+			continue
+		}
 		select {
-		//parse user commands.
-		case txt := <-cmdLines:
-			process = state.processKey(txt)
+		//parse user commands, the end of input means quit.
+		case txt, ok := <-cmdLines:
+			process = ok && state.processKey(txt)
 		//wait for continious actions.
 		case rez := <-state.gameWaiter:
-			terminal.CallClear()
+			state.clearScreen()
 			state.releaseWaitingResources()
 			state.processWaitResult(rez)
+		//the state of the connection is changed.
+		case conn := <-state.connChanges():
+			if conn != state.connState {
+				state.connState = conn
+				fmt.Fprintf(state.out, "Connection: %s\n", Indicator(conn))
+				state.emit(Event{Event: EventConnection, Connection: Indicator(conn)})
+			}
+			continue
 		//OS quit signal interseptor.
 		case <-quit:
 			process = false
@@ -88,15 +123,51 @@ func (state *gameState) printInvitation() {
 	case waitJoin:
 		msg = fmt.Sprintln("\nWaiting for the game to start:\n [q]: - quit from the Lobby.")
 	case waitTurn:
-		msg = stringFromGameData(state.gameData)
+		msg = stringFromGameData(state.gameData, state.marks)
 		msg += fmt.Sprintln("\nWaiting for the turn:\n [q]: - quit from the Lobby.\n [e]: - exith this Game.")
 	case performTurn:
-		msg = stringFromGameData(state.gameData)
-		msg += fmt.Sprintln("\nPlease, make a turn:\n [q]: - quit from the Lobby.\n [e]: - exith this Game.\n [xxx yyy]: - enter coordinates to make a turn.")
+		msg = stringFromGameData(state.gameData, state.marks)
+		msg += fmt.Sprintln("\nPlease, make a turn:\n [q]: - quit from the Lobby.\n [e]: - exith this Game.\n [xxx yyy] or [D4]: - enter coordinates to make a turn.")
 	case gameOver:
 		msg = fmt.Sprintln("\nThe Game is over:\n [q]: - quit from the Lobby.\n [e]: - exith this Game.")
 	}
-	fmt.Println(msg)
+	if monitor := state.session.Monitor(); monitor != nil {
+		state.connState = monitor.State()
+		msg += fmt.Sprintf("Connection: %s\n", Indicator(state.connState))
+	}
+	fmt.Fprintln(state.out, msg)
+	state.emit(Event{Event: EventPrompt, Mode: modeNames[state.currentMode]})
+}
+
+// clearScreen clears the screen before the next picture for the user.
+// Nothing is cleared for the bot, its messages are kept as a log.
+func (state *gameState) clearScreen() {
+	if state.bot == nil {
+		terminal.Clear(state.out)
+	}
+}
+
+// emit reports the event, if events are requested.
+func (state *gameState) emit(ev Event) {
+	if events := state.session.Events(); events != nil {
+		events.Emit(ev)
+	}
+}
+
+// emitError reports the error, if events are requested.
+func (state *gameState) emitError(err error) {
+	if events := state.session.Events(); events != nil {
+		events.EmitError(err)
+	}
+}
+
+// connChanges returns the chanel of changes of the connection state,
+// or nil if the connection is not monitored.
+func (state *gameState) connChanges() <-chan connectivity.State {
+	if monitor := state.session.Monitor(); monitor != nil {
+		return monitor.Changes()
+	}
+	return nil
 }
 
 //releaseWaitingResources releases resources, if any.
@@ -109,17 +180,20 @@ func (state *gameState) releaseWaitingResources() {
 }
 
 //releaseGameResources releases game specific resources.
-func (state *gameState) releaseGameResources() {
+func (state *gameState) releaseGameResources() error {
 	if state.currentMode == waitTurn || state.currentMode == performTurn || state.currentMode == gameOver {
-		terminal.CallClear()
-		if _, err := state.client.LeaveTheGame(context.Background(), &api.EmptyMessage{}); err != nil {
-			st := status.Convert(err)
-			fmt.Printf("Error, while leaving a game: %v: %s", st.Code(), st.Message())
-		} else {
-			state.currentMode = noGame
+		state.clearScreen()
+		if err := state.session.Leave(context.Background()); err != nil {
+			fmt.Fprintf(state.out, "Error, while leaving a game: %s", err)
+			state.emitError(err)
+			return fmt.Errorf("can't leave the game: %w", err)
 		}
+		state.currentMode = noGame
+		state.gameErr = nil
+		state.emit(Event{Event: EventLeft})
 	}
-	fmt.Println("Leave The game...")
+	fmt.Fprintln(state.out, "Leave The game...")
+	return nil
 }
 
 // processWaitResult waits of waiting function result and process it.
@@ -131,29 +205,43 @@ func (state *gameState) processWaitResult(rez interface{}) {
 		if stateErr.err != nil {
 			state.gameData = nil
 			state.currentMode = noGame
-			fmt.Println(stateErr.err)
+			fmt.Fprintln(state.out, stateErr.err)
+			state.emitError(stateErr.err)
+			state.bot.fail(stateErr.err)
 			break
 		}
 		state.currentMode = waitTurn
 		state.gameData = stateErr.gameData
-		state.gameWaiter, state.cancel = waitTurnBegin(state.client)
+		state.emit(Event{Event: EventJoined, State: NewGameState(state.gameData)})
+		state.marks = turnMarks{}
+		state.gameWaiter, state.cancel = waitTurnBegin(state.session)
 	case waitTurn:
 		if stateErr.err != nil {
 			state.gameData = nil
 			state.currentMode = gameOver
-			fmt.Println(stateErr.err)
+			state.gameErr = stateErr.err
+			fmt.Fprintln(state.out, stateErr.err)
+			state.emitError(stateErr.err)
 			break
 		}
 		state.currentMode = performTurn
+		state.marks = newTurnMarks(state.gameData, stateErr.gameData, true)
 		state.gameData = stateErr.gameData
+		state.emit(Event{Event: EventState, State: NewGameState(state.gameData)})
 	}
 
 }
 
+// checkTurnData parses coordinates of the turn given as "x y" pair
+// or in the standard notation like "D4".
+// n is the number of parsed coordinates.
 func (state *gameState) checkTurnData(txt string) (x, y, n int) {
 	if state.currentMode == performTurn && len(txt) > 1 {
 		if ln, err := fmt.Sscanf(txt, "%d %d", &x, &y); err == nil {
-			n = ln
+			return x, y, ln
+		}
+		if p, err := board.ParseNotation(txt, int(state.gameData.GetSize())); err == nil {
+			return p.X, p.Y, 2
 		}
 	}
 	return x, y, n
@@ -168,63 +256,96 @@ func (state *gameState) processKey(txt string) bool {
 		return false
 
 	case txt == "j" && state.currentMode == noGame:
-		state.gameWaiter, state.cancel = waitJoinGame(state.client)
-		state.currentMode = waitJoin
-
-	case txt == "e" && (state.currentMode == waitTurn || state.currentMode == performTurn || state.currentMode == gameOver):
-		state.releaseGameResources()
+		state.joinGame()
 
 	case txt == "e" && (state.currentMode == waitTurn || state.currentMode == performTurn || state.currentMode == gameOver):
 		state.releaseGameResources()
 
 	case state.currentMode == performTurn && n == 2:
-		terminal.CallClear()
-		gameData, err := state.client.MakeTurn(context.Background(), &api.TurnMessage{X: int64(x), Y: int64(y)})
-		if err != nil {
-			st := status.Convert(err)
-			if st.Code() == codes.InvalidArgument {
-				fmt.Println(st.Message())
-				break
-			}
-			// codes.InvalidArgument - the last game data is stil actual
-			state.gameData = nil
-			fmt.Printf("Error, while making a turn. Leave the game: %v: %s", st.Code(), st.Message())
-		}
-		state.currentMode = waitTurn
-		state.gameData = gameData
-		state.gameWaiter, state.cancel = waitTurnBegin(state.client)
+		state.makeTurn(x, y)
 
 	default:
-		fmt.Printf("no command %q in current mode \n", txt)
+		fmt.Fprintf(state.out, "no command %q in current mode \n", txt)
+		state.emitError(fmt.Errorf("no command %q in %s mode", txt, modeNames[state.currentMode]))
 	}
 	return true
 }
 
+// joinGame initiates joining to a game.
+func (state *gameState) joinGame() {
+	state.clearScreen()
+	state.gameWaiter, state.cancel = waitJoinGame(state.session)
+	state.currentMode = waitJoin
+}
+
+// makeTurn places the stone at x, y and initiates awaiting of the next turn.
+// The mode is kept, if the server rejects the turn, so another one may be made.
+func (state *gameState) makeTurn(x, y int) {
+	state.clearScreen()
+	gameData, err := state.session.Move(context.Background(), x, y)
+	if err != nil {
+		// ErrInvalidTurn - the last game data is stil actual
+		var stErr *StatusError
+		if errors.Is(err, ErrInvalidTurn) && errors.As(err, &stErr) {
+			fmt.Fprintln(state.out, stErr.Message)
+			state.emitError(err)
+			return
+		}
+		state.gameData = nil
+		state.gameErr = fmt.Errorf("can't make a turn: %w", err)
+		fmt.Fprintf(state.out, "Error, while making a turn. Leave the game: %s", err)
+		state.emitError(err)
+	} else {
+		state.emit(Event{Event: EventState, State: NewGameState(gameData)})
+	}
+	state.currentMode = waitTurn
+	state.marks = newTurnMarks(state.gameData, gameData, false)
+	state.gameData = gameData
+	state.gameWaiter, state.cancel = waitTurnBegin(state.session)
+}
+
 // manageGame selects a game type, initiate and manage it.
-func manageGame(client api.GoGameClient, quit <-chan interface{}) error {
-	fmt.Println("Whelcome to a Go game")
-	state := &gameState{currentMode: noGame, client: client}
+// User commands are read from in, messages are written to out.
+// Only events are reported if the session has them requested.
+// Commands are given by the bot, if it is not nil, in is not read then.
+func manageGame(session *Session, in io.Reader, out io.Writer, quit <-chan interface{}, bot *bot) (err error) {
+	if session.Events() != nil {
+		out = ioutil.Discard
+	}
+	fmt.Fprintln(out, "Whelcome to a Go game")
+	state := &gameState{currentMode: noGame, session: session, out: out, bot: bot}
+	state.emit(Event{Event: EventLobby})
 	defer state.releaseWaitingResources()
-	defer state.releaseGameResources()
+	defer func() {
+		if releaseErr := state.releaseGameResources(); err == nil {
+			err = releaseErr
+		}
+	}()
 	state.printInvitation()
 
 	//asynchronous scanning of user commands.
 	stopScan := make(chan interface{})
-	cmdLines := scanner(stopScan)
+	var cmdLines <-chan string
+	if bot == nil {
+		cmdLines = scanner(in, stopScan)
+	}
 	defer func(stopScan chan<- interface{}) {
 		close(stopScan)
 	}(stopScan)
 
 	state.processUserCommands(cmdLines, quit)
 
-	return nil
+	if err := state.bot.error(); err != nil {
+		return err
+	}
+	return state.gameErr
 }
 
 // scanner scans input into the chanel in separate goroutine
-func scanner(stopScan <-chan interface{}) <-chan string {
+func scanner(in io.Reader, stopScan <-chan interface{}) <-chan string {
 	lines := make(chan string)
 	go func(stopScan <-chan interface{}, lines chan<- string) {
-		scanner := bufio.NewScanner(os.Stdin)
+		scanner := bufio.NewScanner(in)
 
 	ENDGAME:
 		for scanner.Scan() {
@@ -240,6 +361,7 @@ func scanner(stopScan <-chan interface{}) <-chan string {
 			txt := scanner.Text()
 			lines <- txt
 		}
+		close(lines)
 	}(stopScan, lines)
 	return lines
 }
@@ -247,25 +369,19 @@ func scanner(stopScan <-chan interface{}) <-chan string {
 // waitJoinGame initiates joining to a game.
 // returns chanel to report on success or failure and
 // function of cancellation.
-func waitJoinGame(client api.GoGameClient) (<-chan interface{}, context.CancelFunc) {
-	waitEnded := make(chan interface{})
+func waitJoinGame(session *Session) (<-chan interface{}, context.CancelFunc) {
+	waitEnded := make(chan interface{}, 1)
 	ctx, cancel := context.WithCancel(context.Background())
 
 	go func(chan<- interface{}) {
-		terminal.CallClear()
-		gameState, err := client.JoinTheGame(ctx, &api.EmptyMessage{})
+		gameState, err := session.Join(ctx)
 
 		if err != nil {
-			st := status.Convert(err)
-			waitEnded <- &serverGameState{
-				gameData: nil,
-				err:      fmt.Errorf("can't join a game: %v: %s", st.Code(), st.Message()),
-			}
+			err = fmt.Errorf("can't join a game: %w", err)
 		}
-
 		waitEnded <- &serverGameState{
 			gameData: gameState,
-			err:      nil,
+			err:      err,
 		}
 		close(waitEnded)
 	}(waitEnded)
@@ -276,24 +392,19 @@ func waitJoinGame(client api.GoGameClient) (<-chan interface{}, context.CancelFu
 // waitTurnBegin initiates awaiting of player's turn.
 // returns chanel to report on success or failure and
 // function of cancellation.
-func waitTurnBegin(client api.GoGameClient) (<-chan interface{}, context.CancelFunc) {
-	waitEnded := make(chan interface{})
+func waitTurnBegin(session *Session) (<-chan interface{}, context.CancelFunc) {
+	waitEnded := make(chan interface{}, 1)
 	ctx, cancel := context.WithCancel(context.Background())
 
 	go func(chan<- interface{}) {
-		gameState, err := client.WaitTheTurn(ctx, &api.EmptyMessage{})
+		gameState, err := session.WaitTurn(ctx)
 
 		if err != nil {
-			st := status.Convert(err)
-			waitEnded <- &serverGameState{
-				gameData: nil,
-				err:      fmt.Errorf("can't wait a turn: %v: %s", st.Code(), st.Message()),
-			}
+			err = fmt.Errorf("can't wait a turn: %w", err)
 		}
-
 		waitEnded <- &serverGameState{
 			gameData: gameState,
-			err:      nil,
+			err:      err,
 		}
 		close(waitEnded)
 	}(waitEnded)
@@ -0,0 +1,262 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+	"github.com/yagoggame/grpc_client/client"
+	"github.com/yagoggame/grpc_client/client/clienttest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Prompts of the game loop awaited by tests.
+const (
+	lobbyPrompt = "Select a type of game"
+	turnPrompt  = "Please, make a turn"
+	waitPrompt  = "Waiting for the turn"
+)
+
+// output collects the output of the game loop and lets tests wait for it.
+type output struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	changed chan struct{}
+}
+
+func newOutput() *output {
+	return &output{changed: make(chan struct{})}
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	close(o.changed)
+	o.changed = make(chan struct{})
+	return o.buf.Write(p)
+}
+
+func (o *output) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.String()
+}
+
+// waitFor waits until the output has s at least n times.
+func (o *output) waitFor(t *testing.T, s string, n int) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		o.mu.Lock()
+		found := strings.Count(o.buf.String(), s) >= n
+		changed := o.changed
+		o.mu.Unlock()
+		if found {
+			return
+		}
+		select {
+		case <-changed:
+		case <-timeout:
+			t.Fatalf("%q is not printed %d times, the output:\n%s", s, n, o)
+		}
+	}
+}
+
+// player types commands into the game loop.
+type player struct {
+	t   *testing.T
+	in  *io.PipeWriter
+	out *output
+}
+
+// gameFlow runs client.GameFlow with the player and returns the chanel of its result.
+func gameFlow(t *testing.T, c api.GoGameClient) (*player, <-chan error) {
+	r, w := io.Pipe()
+	out := newOutput()
+	done := make(chan error, 1)
+	go func() {
+		done <- client.GameFlow(c, r, out, nil)
+		r.Close()
+	}()
+	return &player{t: t, in: w, out: out}, done
+}
+
+func (p *player) typeLine(line string) {
+	p.t.Helper()
+	if _, err := io.WriteString(p.in, line+"\n"); err != nil {
+		p.t.Fatalf("can't type %q: %v", line, err)
+	}
+}
+
+// result waits for the game loop to finish.
+func result(t *testing.T, done <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("the game loop is not finished")
+	}
+	return nil
+}
+
+func TestGameFlowScriptedGame(t *testing.T) {
+	fake := clienttest.NewFake(5, 0.5)
+	fake.Script(board.Point{X: 3, Y: 3})
+	p, done := gameFlow(t, fake)
+
+	p.out.waitFor(t, lobbyPrompt, 1)
+	p.typeLine("j")
+	p.out.waitFor(t, turnPrompt, 1)
+	p.typeLine("A5")
+	p.out.waitFor(t, turnPrompt, 2)
+	p.typeLine("2 2")
+	// the script is exhausted, the state of the finished game is shown.
+	p.out.waitFor(t, turnPrompt, 3)
+	p.typeLine("e")
+	p.out.waitFor(t, lobbyPrompt, 2)
+	p.typeLine("q")
+
+	if err := result(t, done); err != nil {
+		t.Fatalf("GameFlow: %v", err)
+	}
+	want := []board.Point{{X: 1, Y: 1}, {X: 2, Y: 2}}
+	if got := fake.Turns(); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("turns: got %v, want %v", got, want)
+	}
+	calls := strings.Join(fake.Calls(), " ")
+	for _, call := range []string{clienttest.LeaveTheGame, clienttest.LeaveTheLobby} {
+		if !strings.Contains(calls, call) {
+			t.Errorf("%s is not called: %s", call, calls)
+		}
+	}
+}
+
+func TestGameFlowInvalidTurn(t *testing.T) {
+	fake := clienttest.NewFake(5, 0.5)
+	fake.Script(board.Point{X: 3, Y: 3})
+	p, done := gameFlow(t, fake)
+
+	p.typeLine("j")
+	p.out.waitFor(t, turnPrompt, 1)
+	p.typeLine("1 1")
+	p.out.waitFor(t, turnPrompt, 2)
+	// the intersection is occupied by the previous turn.
+	p.typeLine("1 1")
+	p.out.waitFor(t, "intersection is occupied", 1)
+	p.out.waitFor(t, turnPrompt, 3)
+	p.typeLine("q")
+
+	if err := result(t, done); err != nil {
+		t.Fatalf("GameFlow: %v", err)
+	}
+	if got := fake.Turns(); len(got) != 1 {
+		t.Errorf("turns: got %v, want the first one only", got)
+	}
+}
+
+func TestGameFlowInjectedErrors(t *testing.T) {
+	t.Run("lobby", func(t *testing.T) {
+		fake := clienttest.NewFake(5, 0.5)
+		fake.InjectError(clienttest.EnterTheLobby, status.Error(codes.Unauthenticated, "wrong password"))
+		_, done := gameFlow(t, fake)
+
+		err := result(t, done)
+		if !errors.Is(err, client.ErrUnauthenticated) {
+			t.Errorf("GameFlow: got %v, want ErrUnauthenticated", err)
+		}
+	})
+
+	t.Run("join", func(t *testing.T) {
+		fake := clienttest.NewFake(5, 0.5)
+		fake.InjectError(clienttest.JoinTheGame, status.Error(codes.Internal, "no games today"))
+		p, done := gameFlow(t, fake)
+
+		p.typeLine("j")
+		p.out.waitFor(t, "no games today", 1)
+		p.out.waitFor(t, lobbyPrompt, 2)
+		// the next attempt succeeds.
+		p.typeLine("j")
+		p.out.waitFor(t, turnPrompt, 1)
+		p.typeLine("q")
+		if err := result(t, done); err != nil {
+			t.Fatalf("GameFlow: %v", err)
+		}
+	})
+
+	t.Run("wait", func(t *testing.T) {
+		fake := clienttest.NewFake(5, 0.5)
+		fake.InjectError(clienttest.WaitTheTurn, status.Error(codes.Aborted, "the opponent left"))
+		p, done := gameFlow(t, fake)
+
+		p.typeLine("j")
+		p.out.waitFor(t, "The Game is over", 1)
+		if !strings.Contains(p.out.String(), "the opponent left") {
+			t.Errorf("the error is not reported:\n%s", p.out)
+		}
+		p.typeLine("q")
+		// the user quits without leaving the aborted game.
+		if err := result(t, done); !errors.Is(err, client.ErrGameAborted) {
+			t.Fatalf("GameFlow: got %v, want ErrGameAborted", err)
+		}
+	})
+
+	t.Run("wait and leave", func(t *testing.T) {
+		fake := clienttest.NewFake(5, 0.5)
+		fake.InjectError(clienttest.WaitTheTurn, status.Error(codes.Aborted, "the opponent left"))
+		p, done := gameFlow(t, fake)
+
+		p.typeLine("j")
+		p.out.waitFor(t, "The Game is over", 1)
+		p.typeLine("e")
+		p.out.waitFor(t, lobbyPrompt, 2)
+		p.typeLine("q")
+		if err := result(t, done); err != nil {
+			t.Fatalf("GameFlow: %v", err)
+		}
+	})
+}
+
+func TestGameFlowCancelsWaiting(t *testing.T) {
+	fake := clienttest.NewFake(5, 0.5)
+	p, done := gameFlow(t, fake)
+
+	p.typeLine("j")
+	p.out.waitFor(t, turnPrompt, 1)
+	// the opponent never moves.
+	fake.SetWaitDelay(-1)
+	p.typeLine("1 1")
+	p.out.waitFor(t, waitPrompt, 1)
+	p.typeLine("q")
+
+	if err := result(t, done); err != nil {
+		t.Fatalf("GameFlow: %v", err)
+	}
+	calls := strings.Join(fake.Calls(), " ")
+	if !strings.HasSuffix(calls, clienttest.LeaveTheGame+" "+clienttest.LeaveTheLobby) {
+		t.Errorf("the game and the lobby are not left after waiting: %s", calls)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// Monitor watches the state of the connection to the server.
+// The connection may be replaced, e.g. after reconnection.
+type Monitor struct {
+	changes chan connectivity.State
+
+	mu     sync.Mutex
+	state  connectivity.State
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewMonitor returns the Monitor watching nothing yet.
+func NewMonitor() *Monitor {
+	return &Monitor{
+		changes: make(chan connectivity.State, 1),
+		state:   connectivity.Idle,
+	}
+}
+
+// Watch starts watching conn instead of the previous connection, if any.
+func (m *Monitor) Watch(conn *grpc.ClientConn) {
+	m.mu.Lock()
+	if m.cancel != nil {
+		m.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.ctx, m.cancel = ctx, cancel
+	m.mu.Unlock()
+
+	go func() {
+		state := conn.GetState()
+		for {
+			m.set(ctx, state)
+			if !conn.WaitForStateChange(ctx, state) {
+				return
+			}
+			state = conn.GetState()
+		}
+	}()
+}
+
+// Stop stops watching.
+func (m *Monitor) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+}
+
+// set remembers the state of the connection watched with ctx
+// and reports it in Changes.
+func (m *Monitor) set(ctx context.Context, state connectivity.State) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// the connection is not watched anymore.
+	if ctx.Err() != nil || m.state == state {
+		return
+	}
+	m.state = state
+
+	// only the last state is of interest.
+	select {
+	case <-m.changes:
+	default:
+	}
+	m.changes <- state
+}
+
+// State returns the last known state of the connection.
+func (m *Monitor) State() connectivity.State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+// Changes returns the chanel to receive states of the connection, when they change.
+// Only the last state is kept, if the receiver is late.
+func (m *Monitor) Changes() <-chan connectivity.State {
+	return m.changes
+}
+
+// Indicator returns a short description of the state of the connection.
+func Indicator(state connectivity.State) string {
+	switch state {
+	case connectivity.Ready:
+		return "connected"
+	case connectivity.Connecting:
+		return "connecting"
+	case connectivity.TransientFailure:
+		return "transient failure"
+	case connectivity.Shutdown:
+		return "closed"
+	}
+	return "idle"
+}
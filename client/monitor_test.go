@@ -0,0 +1,150 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/client"
+	"github.com/yagoggame/grpc_client/client/clienttest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+func TestIndicator(t *testing.T) {
+	tests := []struct {
+		state connectivity.State
+		want  string
+	}{
+		{connectivity.Idle, "idle"},
+		{connectivity.Connecting, "connecting"},
+		{connectivity.Ready, "connected"},
+		{connectivity.TransientFailure, "transient failure"},
+		{connectivity.Shutdown, "closed"},
+	}
+	for _, test := range tests {
+		if got := client.Indicator(test.state); got != test.want {
+			t.Errorf("Indicator(%s) = %q, want %q", test.state, got, test.want)
+		}
+	}
+}
+
+// waitState waits until the monitor reports the state matching want.
+func waitState(t *testing.T, m *client.Monitor, want func(connectivity.State) bool) connectivity.State {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case state := <-m.Changes():
+			if want(state) {
+				if got := m.State(); got != state {
+					t.Errorf("State() = %s, want %s", got, state)
+				}
+				return state
+			}
+		case <-timeout:
+			t.Fatalf("the state is not reported, the last one is %s", m.State())
+		}
+	}
+}
+
+// noChanges checks the monitor reports nothing for a while.
+func noChanges(t *testing.T, m *client.Monitor) {
+	t.Helper()
+	select {
+	case state := <-m.Changes():
+		t.Errorf("unexpected change to %s", state)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func isReady(state connectivity.State) bool {
+	return state == connectivity.Ready
+}
+
+// dialReady connects to the server and makes a call, so the connection is ready.
+func dialReady(t *testing.T, s *clienttest.Server, login string) *grpc.ClientConn {
+	t.Helper()
+	conn, err := s.Connect(s.IniData(login, "secret"))
+	if err != nil {
+		t.Fatalf("can't connect: %v", err)
+	}
+	if err := client.RegisterUser(context.Background(), api.NewGoGameClient(conn)); err != nil {
+		t.Fatalf("RegisterUser: %v", err)
+	}
+	return conn
+}
+
+func TestMonitorWatch(t *testing.T) {
+	s := newServer(t)
+	defer s.Close()
+
+	m := client.NewMonitor()
+	defer m.Stop()
+	if got := m.State(); got != connectivity.Idle {
+		t.Errorf("the state of the new monitor is %s, want %s", got, connectivity.Idle)
+	}
+
+	conn := dialReady(t, s, "alice")
+	defer conn.Close()
+	m.Watch(conn)
+	waitState(t, m, isReady)
+
+	s.DropConnections()
+	waitState(t, m, func(state connectivity.State) bool { return state != connectivity.Ready })
+}
+
+func TestMonitorWatchReplaces(t *testing.T) {
+	s := newServer(t)
+	defer s.Close()
+
+	m := client.NewMonitor()
+	defer m.Stop()
+	old := dialReady(t, s, "alice")
+	m.Watch(old)
+	waitState(t, m, isReady)
+
+	conn := dialReady(t, s, "bob")
+	defer conn.Close()
+	m.Watch(conn)
+	// the old connection is not watched anymore.
+	old.Close()
+	noChanges(t, m)
+	if got := m.State(); got != connectivity.Ready {
+		t.Errorf("State() = %s, want %s", got, connectivity.Ready)
+	}
+
+	conn.Close()
+	waitState(t, m, func(state connectivity.State) bool { return state == connectivity.Shutdown })
+}
+
+func TestMonitorStop(t *testing.T) {
+	s := newServer(t)
+	defer s.Close()
+
+	m := client.NewMonitor()
+	conn := dialReady(t, s, "alice")
+	m.Watch(conn)
+	waitState(t, m, isReady)
+
+	m.Stop()
+	conn.Close()
+	noChanges(t, m)
+}
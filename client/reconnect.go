@@ -0,0 +1,178 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yagoggame/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Dialer establishes a new connection to the server.
+// It is called by the Session when the connection is lost.
+type Dialer func(ctx context.Context) (api.GoGameClient, error)
+
+// Backoff configures delays between attempts to reconnect.
+type Backoff struct {
+	// Initial is the delay before the first attempt.
+	Initial time.Duration
+	// Max limits the delay.
+	Max time.Duration
+	// Factor multiplies the delay after every failed attempt.
+	Factor float64
+	// Attempts limits the number of attempts, 0 means no limit.
+	Attempts int
+}
+
+// DefaultBackoff is the Backoff used when none is given.
+var DefaultBackoff = Backoff{
+	Initial:  time.Second,
+	Max:      30 * time.Second,
+	Factor:   2,
+	Attempts: 10,
+}
+
+// Delay returns the delay before the attempt, starting with 1.
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := float64(b.Initial)
+	for i := 1; i < attempt && (b.Max <= 0 || delay < float64(b.Max)); i++ {
+		delay *= b.Factor
+	}
+	if b.Max > 0 && delay > float64(b.Max) {
+		return b.Max
+	}
+	return time.Duration(delay)
+}
+
+// WithReconnect makes the session reconnect with dial, when the connection is lost.
+// After reconnection the session enters the lobby again and repeats the failed call,
+// so the game is resumed with the last known state.
+// Turns and leaving of the game are not repeated blindly:
+// the server may have done them before the connection was lost.
+// notify, if not nil, is called before every attempt.
+func WithReconnect(dial Dialer, backoff Backoff, notify func(attempt int, err error)) SessionOption {
+	return func(s *Session) {
+		s.dial = dial
+		s.backoff = backoff
+		if notify != nil {
+			s.notify = append(s.notify, notify)
+		}
+	}
+}
+
+// WithReconnectNotify adds notify to be called before every attempt to reconnect,
+// e.g. by the front end which is created after the options of reconnection are set.
+func WithReconnectNotify(notify func(attempt int, err error)) SessionOption {
+	return func(s *Session) {
+		s.notify = append(s.notify, notify)
+	}
+}
+
+// call performs fn with the current client and the context limited with the timeout of op.
+// If the connection is lost, the session reconnects, if able,
+// and repeats fn. Errors are wrapped with newStatusError.
+// Only calls which may be safely repeated are done with call.
+func (s *Session) call(ctx context.Context, op string, kinds map[codes.Code]error, fn func(context.Context, api.GoGameClient) error) error {
+	for {
+		reconnected, err := s.callOnce(ctx, op, kinds, fn)
+		if !reconnected {
+			return err
+		}
+	}
+}
+
+// callOnce performs fn like call, but doesn't repeat it after reconnection.
+// It reports whether the session is reconnected instead,
+// so the caller finds out whether the server has done the call.
+func (s *Session) callOnce(ctx context.Context, op string, kinds map[codes.Code]error, fn func(context.Context, api.GoGameClient) error) (reconnected bool, err error) {
+	timeout := s.timeouts.For(op)
+	c := s.conn()
+	callCtx, cancel := WithTimeout(ctx, timeout)
+	err = fn(callCtx, c)
+	cancel()
+	if err == nil {
+		return false, nil
+	}
+	err = explainTimeout(ctx, newStatusError(op, err, kinds), timeout)
+	if s.dial == nil || !errors.Is(err, ErrUnavailable) {
+		return false, err
+	}
+	if err := s.reconnect(ctx, c, err); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// conn returns the current client.
+func (s *Session) conn() api.GoGameClient {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client
+}
+
+// reconnect replaces the failed client with a new one and enters the lobby.
+// Nothing is done if another call has already replaced the failed client.
+func (s *Session) reconnect(ctx context.Context, failed api.GoGameClient, cause error) error {
+	s.reconnectMu.Lock()
+	defer s.reconnectMu.Unlock()
+	if s.conn() != failed {
+		return nil
+	}
+
+	for attempt := 1; s.backoff.Attempts <= 0 || attempt <= s.backoff.Attempts; attempt++ {
+		for _, notify := range s.notify {
+			notify(attempt, cause)
+		}
+		select {
+		case <-time.After(s.backoff.Delay(attempt)):
+		case <-ctx.Done():
+			return cause
+		}
+
+		c, err := s.dial(ctx)
+		if err == nil {
+			err = s.enterLobby(ctx, c)
+		}
+		if err == nil {
+			s.mu.Lock()
+			s.client = c
+			s.mu.Unlock()
+			return nil
+		}
+		cause = err
+	}
+	return fmt.Errorf("can't reconnect after %d attempts: %w", s.backoff.Attempts, cause)
+}
+
+// enterLobby enters the lobby with the new client.
+// The server may keep the user in the lobby after the connection is lost,
+// it is not an error.
+func (s *Session) enterLobby(ctx context.Context, c api.GoGameClient) error {
+	timeout := s.timeouts.For("EnterTheLobby")
+	callCtx, cancel := WithTimeout(ctx, timeout)
+	defer cancel()
+	_, err := c.EnterTheLobby(callCtx, &api.EmptyMessage{})
+	if err != nil && status.Code(err) != codes.AlreadyExists {
+		return explainTimeout(ctx, newStatusError("EnterTheLobby", err, nil), timeout)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+	"github.com/yagoggame/grpc_client/client"
+	"github.com/yagoggame/grpc_client/client/clienttest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// lostReply is the client whose connection is lost after the server
+// has done the call, but before the reply is received.
+type lostReply struct {
+	*clienttest.Fake
+	lose map[string]bool
+}
+
+func (c *lostReply) MakeTurn(ctx context.Context, in *api.TurnMessage, opts ...grpc.CallOption) (*api.State, error) {
+	state, err := c.Fake.MakeTurn(ctx, in, opts...)
+	if c.lose[clienttest.MakeTurn] {
+		c.lose[clienttest.MakeTurn] = false
+		return nil, status.Error(codes.Unavailable, "connection lost")
+	}
+	return state, err
+}
+
+func (c *lostReply) LeaveTheGame(ctx context.Context, in *api.EmptyMessage, opts ...grpc.CallOption) (*api.EmptyMessage, error) {
+	rez, err := c.Fake.LeaveTheGame(ctx, in, opts...)
+	if c.lose[clienttest.LeaveTheGame] {
+		c.lose[clienttest.LeaveTheGame] = false
+		return nil, status.Error(codes.Unavailable, "connection lost")
+	}
+	return rez, err
+}
+
+// reconnectingSession returns the session in the game of the client,
+// which is dialed again when the connection is lost.
+func reconnectingSession(t *testing.T, c api.GoGameClient) *client.Session {
+	ctx := context.Background()
+	dial := func(ctx context.Context) (api.GoGameClient, error) {
+		return c, nil
+	}
+	s, err := client.NewSession(ctx, c,
+		client.WithReconnect(dial, client.Backoff{Initial: time.Millisecond, Attempts: 1}, nil))
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	if _, err := s.Join(ctx); err != nil {
+		t.Fatalf("Join: %v", err)
+	}
+	if _, err := s.WaitTurn(ctx); err != nil {
+		t.Fatalf("WaitTurn: %v", err)
+	}
+	return s
+}
+
+func TestMoveIsNotRepeatedAfterLostReply(t *testing.T) {
+	fake := clienttest.NewFake(5, 0.5)
+	fake.Script(board.Point{X: 3, Y: 3})
+	c := &lostReply{Fake: fake, lose: map[string]bool{clienttest.MakeTurn: true}}
+	s := reconnectingSession(t, c)
+
+	state, err := s.Move(context.Background(), 1, 1)
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if turns := fake.Turns(); len(turns) != 1 {
+		t.Errorf("turns made: got %v, want [{1 1}]", turns)
+	}
+	// the state is obtained when it is the user's turn again.
+	if white := state.GetWhite().GetChipsOnBoard(); len(white) != 1 {
+		t.Errorf("white stones: got %v, want the opponent's turn", white)
+	}
+}
+
+func TestMoveIsRepeatedAfterReconnection(t *testing.T) {
+	fake := clienttest.NewFake(5, 0.5)
+	fake.Script(board.Point{X: 3, Y: 3})
+	fake.InjectError(clienttest.MakeTurn, status.Error(codes.Unavailable, "connection lost"))
+	s := reconnectingSession(t, fake)
+
+	state, err := s.Move(context.Background(), 1, 1)
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if turns := fake.Turns(); len(turns) != 1 {
+		t.Errorf("turns made: got %v, want [{1 1}]", turns)
+	}
+	if black := state.GetBlack().GetChipsOnBoard(); len(black) != 1 {
+		t.Errorf("black stones: got %v, want the user's turn", black)
+	}
+	if white := state.GetWhite().GetChipsOnBoard(); len(white) != 0 {
+		t.Errorf("white stones: got %v, want none", white)
+	}
+}
+
+func TestLeaveAfterLostReply(t *testing.T) {
+	fake := clienttest.NewFake(5, 0.5)
+	c := &lostReply{Fake: fake, lose: map[string]bool{clienttest.LeaveTheGame: true}}
+	s := reconnectingSession(t, c)
+
+	if err := s.Leave(context.Background()); err != nil {
+		t.Fatalf("Leave: %v", err)
+	}
+	if s.InGame() {
+		t.Errorf("the session is still in the game")
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/record"
+	"github.com/yagoggame/grpc_client/terminal"
+)
+
+// DrawState returns the picture of the board with the state of the game.
+// Stones placed and captured since prev, if not nil, are marked.
+func DrawState(prev, state *api.State) string {
+	return stringFromGameData(state, newTurnMarks(prev, state, true))
+}
+
+// replayState is type to hold current position of the replay.
+type replayState struct {
+	game   *record.Game
+	states []*api.State
+	cur    int
+	out    io.Writer
+	msg    string
+}
+
+// Replay shows the recorded game step by step.
+// Navigation commands are read from in, the board is drawn to out.
+func Replay(game *record.Game, in io.Reader, out io.Writer) error {
+	state := &replayState{game: game, states: game.States(), out: out}
+	if len(state.states) == 0 {
+		return fmt.Errorf("the game record is empty")
+	}
+	state.show()
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		if !state.processKey(strings.TrimSpace(scanner.Text())) {
+			return nil
+		}
+		state.show()
+	}
+	return scanner.Err()
+}
+
+// processKey processes navigation command, it returns false to quit.
+func (state *replayState) processKey(txt string) bool {
+	last := len(state.states) - 1
+	fields := strings.Fields(txt)
+	state.msg = ""
+
+	switch {
+	case txt == "q":
+		return false
+	case txt == "" || txt == "n":
+		state.jump(state.cur + 1)
+	case txt == "p" || txt == "b":
+		state.jump(state.cur - 1)
+	case txt == "f":
+		state.jump(0)
+	case txt == "l":
+		state.jump(last)
+	case len(fields) == 2 && fields[0] == "g":
+		txt = fields[1]
+		fallthrough
+	default:
+		n, err := strconv.Atoi(txt)
+		if err != nil {
+			state.msg = fmt.Sprintf("no command %q", txt)
+			break
+		}
+		state.jump(n)
+	}
+	return true
+}
+
+// jump moves to the position n, if it exists.
+func (state *replayState) jump(n int) {
+	if n < 0 || n >= len(state.states) {
+		state.msg = fmt.Sprintf("no position %d, positions are 0..%d", n, len(state.states)-1)
+		return
+	}
+	state.cur = n
+}
+
+// show draws the current position with navigation hints.
+func (state *replayState) show() {
+	terminal.Clear(state.out)
+
+	var prev *api.State
+	if state.cur > 0 {
+		prev = state.states[state.cur-1]
+	}
+	fmt.Fprintf(state.out, "Black: %s White: %s Result: %s\n",
+		record.PlayerName(state.game.Black), record.PlayerName(state.game.White), state.game.Result())
+	fmt.Fprintf(state.out, "Position %d of %d\n", state.cur, len(state.states)-1)
+	fmt.Fprint(state.out, DrawState(prev, state.states[state.cur]))
+	if state.msg != "" {
+		fmt.Fprintln(state.out, state.msg)
+	}
+	fmt.Fprintln(state.out, "\n [n] or [Enter]: - next.\n [p]: - previous.\n [f] [l]: - first, last.\n [g N] or [N]: - jump to the position N.\n [q]: - quit.")
+}
@@ -0,0 +1,96 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client_test
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/yagoggame/grpc_client/client"
+	"github.com/yagoggame/grpc_client/record"
+)
+
+// positions returns numbers of positions shown by the replay in order.
+func positions(out string) []string {
+	var rez []string
+	for _, m := range regexp.MustCompile(`Position (\d+) of \d+`).FindAllStringSubmatch(out, -1) {
+		rez = append(rez, m[1])
+	}
+	return rez
+}
+
+func TestReplayNavigation(t *testing.T) {
+	game, err := record.ReadSGF(strings.NewReader("(;SZ[5]PB[alice]PW[bob]RE[W+0.5];B[aa];W[bb];B[cc];W[dd])"))
+	if err != nil {
+		t.Fatalf("ReadSGF: %v", err)
+	}
+	commands := []string{
+		"n", "", // next
+		"p", "b", // previous
+		"l",   // last
+		"n",   // no position after the last one
+		"f",   // first
+		"p",   // no position before the first one
+		"g 3", // jump
+		"2",   // jump
+		"g 9", // no such position
+		"x",   // no such command
+		"q",
+		"n", // not read after quit
+	}
+	var out bytes.Buffer
+	if err := client.Replay(game, strings.NewReader(strings.Join(commands, "\n")), &out); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+
+	want := "0 1 2 1 0 4 4 0 0 3 2 2 2"
+	if got := strings.Join(positions(out.String()), " "); got != want {
+		t.Errorf("positions: got %s, want %s", got, want)
+	}
+	for _, msg := range []string{
+		"Black: alice White: bob Result: W+0.5",
+		"Position 0 of 4",
+		"no position 5, positions are 0..4",
+		"no position -1, positions are 0..4",
+		"no position 9, positions are 0..4",
+		`no command "x"`,
+	} {
+		if !strings.Contains(out.String(), msg) {
+			t.Errorf("%q is not printed", msg)
+		}
+	}
+}
+
+func TestReplayEndOfInput(t *testing.T) {
+	game, err := record.ReadSGF(strings.NewReader("(;SZ[5];B[aa])"))
+	if err != nil {
+		t.Fatalf("ReadSGF: %v", err)
+	}
+	var out bytes.Buffer
+	if err := client.Replay(game, strings.NewReader("l\n"), &out); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	// the last position marks the last move.
+	if got := strings.Join(positions(out.String()), " "); got != "0 1" {
+		t.Errorf("positions: got %s, want 0 1", got)
+	}
+	if !strings.Contains(out.String(), "Last move (◆/◇): A5") {
+		t.Errorf("the last move is not marked:\n%s", out.String())
+	}
+}
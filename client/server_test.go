@@ -0,0 +1,72 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/yagoggame/grpc_client/client"
+)
+
+func TestGameFlowWithServer(t *testing.T) {
+	s := newServer(t)
+	defer s.Close()
+	s.AddUser("black", "1")
+	s.AddUser("white", "2")
+	blackClient, closeBlack := connect(t, s, "black", "1")
+	defer closeBlack()
+	whiteClient, closeWhite := connect(t, s, "white", "2")
+	defer closeWhite()
+
+	black, blackDone := gameFlow(t, blackClient)
+	black.typeLine("j")
+	black.out.waitFor(t, "Waiting for the game to start", 1)
+	white, whiteDone := gameFlow(t, whiteClient)
+	white.typeLine("j")
+
+	black.out.waitFor(t, turnPrompt, 1)
+	black.typeLine("C3")
+	white.out.waitFor(t, turnPrompt, 1)
+	white.typeLine("D4")
+	black.out.waitFor(t, turnPrompt, 2)
+
+	// the opponent leaves, the game is aborted.
+	white.typeLine("q")
+	if err := result(t, whiteDone); err != nil {
+		t.Fatalf("GameFlow of white: %v", err)
+	}
+	black.typeLine("A1")
+	black.out.waitFor(t, "The Game is over", 1)
+	black.typeLine("q")
+	if err := result(t, blackDone); !errors.Is(err, client.ErrGameAborted) {
+		t.Fatalf("GameFlow of black: got %v, want ErrGameAborted", err)
+	}
+}
+
+func TestGameFlowWrongPassword(t *testing.T) {
+	s := newServer(t)
+	defer s.Close()
+	s.AddUser("black", "1")
+	c, closeConn := connect(t, s, "black", "wrong")
+	defer closeConn()
+
+	_, done := gameFlow(t, c)
+	if err := result(t, done); !errors.Is(err, client.ErrUnauthenticated) {
+		t.Errorf("GameFlow: got %v, want ErrUnauthenticated", err)
+	}
+}
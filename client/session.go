@@ -0,0 +1,352 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+	"github.com/yagoggame/grpc_client/record"
+	"google.golang.org/grpc/codes"
+)
+
+// Session is a stay of the user in the lobby of grpc_server.
+// It is safe to call its methods from several goroutines,
+// e.g. to Leave the game while WaitTurn is in progress.
+type Session struct {
+	recorder func(*record.Game)
+	dial     Dialer
+	backoff  Backoff
+	notify   []func(attempt int, err error)
+	monitor  *Monitor
+	timeouts Timeouts
+	events   *EventWriter
+
+	// reconnectMu serializes reconnections.
+	reconnectMu sync.Mutex
+
+	mu      sync.Mutex
+	client  api.GoGameClient
+	inLobby bool
+	inGame  bool
+	state   *api.State
+	colour  board.Colour
+	record  *record.Game
+}
+
+// SessionOption configures the Session.
+type SessionOption func(*Session)
+
+// WithRecorder sets the function to be called with the record of every game,
+// when the game is over or left.
+func WithRecorder(recorder func(*record.Game)) SessionOption {
+	return func(s *Session) {
+		s.recorder = recorder
+	}
+}
+
+// WithMonitor sets the monitor of the connection to be shown to the user.
+func WithMonitor(monitor *Monitor) SessionOption {
+	return func(s *Session) {
+		s.monitor = monitor
+	}
+}
+
+// NewSession enters the lobby and returns the session.
+// Calls are limited with DefaultTimeouts, unless WithTimeouts is given.
+func NewSession(ctx context.Context, client api.GoGameClient, opts ...SessionOption) (*Session, error) {
+	s := &Session{client: client, timeouts: DefaultTimeouts}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	timeout := s.timeouts.For("EnterTheLobby")
+	callCtx, cancel := WithTimeout(ctx, timeout)
+	defer cancel()
+	if _, err := client.EnterTheLobby(callCtx, &api.EmptyMessage{}); err != nil {
+		return nil, explainTimeout(ctx, newStatusError("EnterTheLobby", err, nil), timeout)
+	}
+	s.inLobby = true
+	return s, nil
+}
+
+// check returns an error if the session is not in the lobby,
+// or not in the game when inGame is requested.
+func (s *Session) check(inGame bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case !s.inLobby:
+		return ErrNotInLobby
+	case inGame && !s.inGame:
+		return ErrNotInGame
+	}
+	return nil
+}
+
+// update remembers the state obtained from the server.
+// own is set if the state is obtained after the user's turn.
+func (s *Session) update(state *api.State, own bool) {
+	s.mu.Lock()
+	s.state = state
+	if s.record != nil {
+		if own {
+			s.record.AddOwn(state)
+		} else {
+			s.record.Add(state)
+		}
+	}
+	s.mu.Unlock()
+
+	if state.GetGameOver() {
+		s.finishRecord()
+	}
+}
+
+// detectColour detects the colour of the user's stones, if it is unknown yet.
+// Stones placed since the previous state are the user's ones after the user's turn
+// and the opponent's ones, when it is the user's turn.
+// The user has black stones, if the board is empty when it is their turn.
+func (s *Session) detectColour(state *api.State, own bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.colour != board.Empty || state.GetGameOver() {
+		return
+	}
+
+	cur := board.FromState(state)
+	added, _ := cur.Diff(board.FromState(s.state))
+	placed := board.Empty
+	for _, p := range added {
+		if placed != board.Empty && cur.At(p) != placed {
+			return
+		}
+		placed = cur.At(p)
+	}
+	switch {
+	case placed == board.Empty && !own && len(cur.Stones(board.Black))+len(cur.Stones(board.White)) == 0:
+		s.colour = board.Black
+	case placed == board.Empty:
+	case own:
+		s.colour = placed
+	default:
+		s.colour = placed.Opponent()
+	}
+}
+
+// Colour returns the colour of the user's stones in the current game,
+// Empty if it is not known yet. It is known, when it is the user's turn.
+func (s *Session) Colour() board.Colour {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.colour
+}
+
+// finishRecord passes the record of the game to the recorder once.
+func (s *Session) finishRecord() {
+	s.mu.Lock()
+	rec := s.record
+	s.record = nil
+	s.mu.Unlock()
+
+	if rec != nil && s.recorder != nil {
+		s.recorder(rec)
+	}
+}
+
+// Record returns the record of the current game,
+// or nil if there is no game or it is already over.
+func (s *Session) Record() *record.Game {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.record
+}
+
+// Join waits for a partner and starts a game with them.
+func (s *Session) Join(ctx context.Context) (*api.State, error) {
+	if err := s.check(false); err != nil {
+		return nil, err
+	}
+	if s.InGame() {
+		return nil, ErrAlreadyInGame
+	}
+
+	var state *api.State
+	err := s.call(ctx, "JoinTheGame", nil, func(ctx context.Context, c api.GoGameClient) (err error) {
+		state, err = c.JoinTheGame(ctx, &api.EmptyMessage{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.inGame = true
+	s.state = nil
+	s.colour = board.Empty
+	s.record = record.NewGame()
+	s.mu.Unlock()
+	s.update(state, false)
+	return state, nil
+}
+
+// WaitTurn waits until it is the user's turn or the game is over.
+// The game left by the opponent is reported with ErrGameAborted.
+func (s *Session) WaitTurn(ctx context.Context) (*api.State, error) {
+	if err := s.check(true); err != nil {
+		return nil, err
+	}
+
+	var state *api.State
+	err := s.call(ctx, "WaitTheTurn", map[codes.Code]error{
+		codes.Aborted: ErrGameAborted,
+	}, func(ctx context.Context, c api.GoGameClient) (err error) {
+		state, err = c.WaitTheTurn(ctx, &api.EmptyMessage{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.detectColour(state, false)
+	s.update(state, false)
+	return state, nil
+}
+
+// Move makes a turn at the point x, y.
+// The turn rejected by the server is reported with ErrInvalidTurn.
+// If the connection is lost during the turn, the state of the game
+// is obtained after reconnection: the turn is made again, if the state is not changed,
+// otherwise the state, obtained when it is the user's turn again, is returned.
+func (s *Session) Move(ctx context.Context, x, y int) (*api.State, error) {
+	if err := s.check(true); err != nil {
+		return nil, err
+	}
+
+	var state *api.State
+	for {
+		reconnected, err := s.callOnce(ctx, "MakeTurn", map[codes.Code]error{
+			codes.InvalidArgument: ErrInvalidTurn,
+			codes.Aborted:         ErrGameAborted,
+		}, func(ctx context.Context, c api.GoGameClient) (err error) {
+			state, err = c.MakeTurn(ctx, &api.TurnMessage{X: int64(x), Y: int64(y)})
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !reconnected {
+			break
+		}
+
+		// the turn is made by the server, if the game is changed.
+		prev := s.State()
+		cur, err := s.WaitTurn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !proto.Equal(prev, cur) {
+			return cur, nil
+		}
+	}
+	s.detectColour(state, true)
+	s.update(state, true)
+	return state, nil
+}
+
+// Leave leaves the current game.
+func (s *Session) Leave(ctx context.Context) error {
+	if err := s.check(true); err != nil {
+		return err
+	}
+
+	s.finishRecord()
+	leave := func(ctx context.Context, c api.GoGameClient) error {
+		_, err := c.LeaveTheGame(ctx, &api.EmptyMessage{})
+		return err
+	}
+	reconnected, err := s.callOnce(ctx, "LeaveTheGame", nil, leave)
+	if reconnected {
+		// the server may have removed the user from the game before the connection was lost.
+		err = s.call(ctx, "LeaveTheGame", map[codes.Code]error{
+			codes.FailedPrecondition: ErrNotInGame,
+		}, leave)
+		if errors.Is(err, ErrNotInGame) {
+			err = nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inGame = false
+	s.state = nil
+	return nil
+}
+
+// State returns the last state of the game obtained from the server,
+// or nil if there is no game.
+func (s *Session) State() *api.State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state
+}
+
+// Monitor returns the monitor of the connection, or nil if it is not set.
+func (s *Session) Monitor() *Monitor {
+	return s.monitor
+}
+
+// InGame reports whether the user plays a game.
+func (s *Session) InGame() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.inGame
+}
+
+// Close leaves the game, if any, and the lobby.
+// The lobby is left even if leaving the game fails.
+func (s *Session) Close(ctx context.Context) error {
+	var leaveErr error
+	if s.InGame() {
+		leaveErr = s.Leave(ctx)
+	}
+	if err := s.check(false); err != nil {
+		return err
+	}
+
+	err := s.call(ctx, "LeaveTheLobby", map[codes.Code]error{
+		codes.FailedPrecondition: ErrNotInLobby,
+	}, func(ctx context.Context, c api.GoGameClient) error {
+		_, err := c.LeaveTheLobby(ctx, &api.EmptyMessage{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inLobby = false
+	s.inGame = false
+	return leaveErr
+}
@@ -0,0 +1,80 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/yagoggame/grpc_client/board"
+	"github.com/yagoggame/grpc_client/client"
+	"github.com/yagoggame/grpc_client/client/clienttest"
+)
+
+func TestSessionGame(t *testing.T) {
+	fake := clienttest.NewFake(5, 0.5)
+	fake.Script(board.Point{X: 3, Y: 3})
+	ctx := context.Background()
+
+	s, err := client.NewSession(ctx, fake)
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	if _, err := s.Move(ctx, 1, 1); !errors.Is(err, client.ErrNotInGame) {
+		t.Errorf("Move before Join: got %v, want ErrNotInGame", err)
+	}
+	if _, err := s.Join(ctx); err != nil {
+		t.Fatalf("Join: %v", err)
+	}
+	if _, err := s.Join(ctx); !errors.Is(err, client.ErrAlreadyInGame) {
+		t.Errorf("second Join: got %v, want ErrAlreadyInGame", err)
+	}
+	if _, err := s.WaitTurn(ctx); err != nil {
+		t.Fatalf("WaitTurn: %v", err)
+	}
+	state, err := s.Move(ctx, 1, 1)
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if s.State() != state {
+		t.Error("the state of the turn is not kept")
+	}
+	if _, err := s.WaitTurn(ctx); err != nil {
+		t.Fatalf("WaitTurn: %v", err)
+	}
+	var stErr *client.StatusError
+	if _, err := s.Move(ctx, 3, 3); !errors.Is(err, client.ErrInvalidTurn) || !errors.As(err, &stErr) || stErr.Op != "MakeTurn" {
+		t.Errorf("Move to the occupied point: got %v, want ErrInvalidTurn of MakeTurn", err)
+	}
+
+	if err := s.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if s.InGame() || s.State() != nil {
+		t.Error("the game is kept after Close")
+	}
+	if _, err := s.Join(ctx); !errors.Is(err, client.ErrNotInLobby) {
+		t.Errorf("Join after Close: got %v, want ErrNotInLobby", err)
+	}
+	want := []string{clienttest.EnterTheLobby, clienttest.JoinTheGame, clienttest.WaitTheTurn, clienttest.MakeTurn,
+		clienttest.WaitTheTurn, clienttest.MakeTurn, clienttest.LeaveTheGame, clienttest.LeaveTheLobby}
+	if got := fake.Calls(); !reflect.DeepEqual(got, want) {
+		t.Errorf("calls: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Timeouts limits the duration of calls to the server.
+type Timeouts struct {
+	// Call limits unary calls, 0 means no limit.
+	Call time.Duration
+	// Wait limits long-poll calls JoinTheGame and WaitTheTurn,
+	// which wait for another player, 0 means no limit.
+	Wait time.Duration
+}
+
+// DefaultTimeouts are the Timeouts used when none are given.
+var DefaultTimeouts = Timeouts{
+	Call: 10 * time.Second,
+}
+
+// longPolls are the calls waiting for another player.
+var longPolls = map[string]bool{
+	"JoinTheGame": true,
+	"WaitTheTurn": true,
+}
+
+// WithTimeouts sets the limits of the duration of calls.
+func WithTimeouts(timeouts Timeouts) SessionOption {
+	return func(s *Session) {
+		s.timeouts = timeouts
+	}
+}
+
+// For returns the timeout of the call op.
+func (t Timeouts) For(op string) time.Duration {
+	if longPolls[op] {
+		return t.Wait
+	}
+	return t.Call
+}
+
+// WithTimeout returns the context with the deadline after timeout,
+// or without a deadline if timeout is 0.
+func WithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// explainTimeout makes the message of the error clear,
+// if the call is not finished within timeout given to it.
+// ctx is the context of the caller, its own deadline is not explained.
+func explainTimeout(ctx context.Context, err error, timeout time.Duration) error {
+	var stErr *StatusError
+	if timeout > 0 && ctx.Err() == nil && errors.Is(err, ErrDeadlineExceeded) && errors.As(err, &stErr) {
+		stErr.Message = fmt.Sprintf("no response from the server within %s", timeout)
+	}
+	return err
+}
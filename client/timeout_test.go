@@ -0,0 +1,87 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTimeoutsFor(t *testing.T) {
+	timeouts := Timeouts{Call: time.Second, Wait: time.Minute}
+	tests := []struct {
+		op   string
+		want time.Duration
+	}{
+		{"JoinTheGame", time.Minute},
+		{"WaitTheTurn", time.Minute},
+		{"MakeTurn", time.Second},
+		{"EnterTheLobby", time.Second},
+		{"LeaveTheGame", time.Second},
+	}
+	for _, test := range tests {
+		if got := timeouts.For(test.op); got != test.want {
+			t.Errorf("For(%q) = %s, want %s", test.op, got, test.want)
+		}
+	}
+	// long polls are not limited by default.
+	if got := DefaultTimeouts.For("WaitTheTurn"); got != 0 {
+		t.Errorf("the default timeout of WaitTheTurn is %s, want no limit", got)
+	}
+}
+
+func TestExplainTimeout(t *testing.T) {
+	deadline := func() error {
+		return newStatusError("MakeTurn", status.Error(codes.DeadlineExceeded, "context deadline exceeded"), nil)
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		err     error
+		timeout time.Duration
+		want    string
+	}{
+		{"exceeded", context.Background(), deadline(), time.Second, "no response from the server within 1s"},
+		{"no timeout", context.Background(), deadline(), 0, "context deadline exceeded"},
+		{"caller's deadline", canceled, deadline(), time.Second, "context deadline exceeded"},
+		{"other error", context.Background(),
+			newStatusError("MakeTurn", status.Error(codes.Unavailable, "connection lost"), nil), time.Second, "connection lost"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := explainTimeout(test.ctx, test.err, test.timeout)
+			var stErr *StatusError
+			if !errors.As(err, &stErr) {
+				t.Fatalf("got %v, want StatusError", err)
+			}
+			if stErr.Message != test.want {
+				t.Errorf("message: got %q, want %q", stErr.Message, test.want)
+			}
+		})
+	}
+	if err := explainTimeout(context.Background(), nil, time.Second); err != nil {
+		t.Errorf("explainTimeout(nil) = %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// transportCredentials returns TLS credentials to connect to the server.
+// The server is verified with CertFile, CAFiles and the system pool, if requested.
+// The system pool is used if no certificates are given.
+// The client certificate is presented, if given.
+func transportCredentials(initData *IniDataContainer) (credentials.TransportCredentials, error) {
+	roots, err := certPool(initData)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		RootCAs:    roots,
+		ServerName: initData.ServerName,
+	}
+
+	switch {
+	case initData.ClientCertFile != "" && initData.ClientKeyFile != "":
+		cert, err := tls.LoadX509KeyPair(initData.ClientCertFile, initData.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	case initData.ClientCertFile != "" || initData.ClientKeyFile != "":
+		return nil, errors.New("both client certificate and its key should be specified")
+	}
+
+	return credentials.NewTLS(config), nil
+}
+
+// certPool returns the pool of certificates of authorities trusted by the client.
+func certPool(initData *IniDataContainer) (*x509.CertPool, error) {
+	files := initData.CAFiles
+	if initData.CertFile != "" {
+		files = append([]string{initData.CertFile}, files...)
+	}
+
+	pool := x509.NewCertPool()
+	if initData.SystemRoots || len(files) == 0 {
+		system, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("could not load system certificates: %w", err)
+		}
+		pool = system
+	}
+
+	for _, file := range files {
+		pem, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read certificates: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", file)
+		}
+	}
+	return pool, nil
+}
@@ -0,0 +1,134 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/client"
+	"github.com/yagoggame/grpc_client/client/clienttest"
+)
+
+// register connects to the server with initData and registers the user.
+// Errors of the handshake are returned by the call.
+func register(t *testing.T, s *clienttest.Server, initData *client.IniDataContainer) error {
+	t.Helper()
+	conn, err := s.Connect(initData)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return client.RegisterUser(ctx, api.NewGoGameClient(conn))
+}
+
+func TestConnectClientCert(t *testing.T) {
+	s := newServer(t)
+	defer s.Close()
+	s.RequireClientCert()
+
+	if err := register(t, s, s.IniData("alice", "secret")); !errors.Is(err, client.ErrUnavailable) {
+		t.Errorf("without the client certificate: got %v, want ErrUnavailable", err)
+	}
+
+	initData := s.IniData("alice", "secret")
+	var err error
+	initData.ClientCertFile, initData.ClientKeyFile, err = s.ClientCert("alice")
+	if err != nil {
+		t.Fatalf("ClientCert: %v", err)
+	}
+	if err := register(t, s, initData); err != nil {
+		t.Errorf("with the client certificate: %v", err)
+	}
+
+	// the certificate is useless without its key.
+	initData.ClientKeyFile = ""
+	if _, err := s.Connect(initData); err == nil {
+		t.Error("connected with the certificate without its key")
+	}
+}
+
+func TestConnectServerName(t *testing.T) {
+	s := newServer(t)
+	defer s.Close()
+
+	// the certificate of the server is issued for localhost.
+	initData := s.IniData("alice", "secret")
+	initData.IP = "go.example.com"
+	if err := register(t, s, initData); !errors.Is(err, client.ErrUnavailable) {
+		t.Errorf("with the name of another server: got %v, want ErrUnavailable", err)
+	}
+
+	initData.ServerName = "localhost"
+	if err := register(t, s, initData); err != nil {
+		t.Errorf("with --server-name: %v", err)
+	}
+}
+
+func TestConnectCAFiles(t *testing.T) {
+	s := newServer(t)
+	defer s.Close()
+	other := newServer(t)
+	defer other.Close()
+	dir, err := ioutil.TempDir("", "grpc_client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the bundle of several authorities trusts the server.
+	var bundle []byte
+	for _, file := range []string{other.CertFile, s.CertFile} {
+		pem, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bundle = append(bundle, pem...)
+	}
+	bundleFile := filepath.Join(dir, "bundle.pem")
+	emptyFile := filepath.Join(dir, "empty.pem")
+	for file, content := range map[string][]byte{bundleFile: bundle, emptyFile: []byte("no certificates\n")} {
+		if err := ioutil.WriteFile(file, content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	initData := s.IniData("alice", "secret")
+	initData.CertFile = ""
+	initData.CAFiles = []string{bundleFile}
+	if err := register(t, s, initData); err != nil {
+		t.Errorf("with the bundle: %v", err)
+	}
+
+	initData.CAFiles = []string{other.CertFile}
+	if err := register(t, s, initData); !errors.Is(err, client.ErrUnavailable) {
+		t.Errorf("with another authority: got %v, want ErrUnavailable", err)
+	}
+
+	initData.CAFiles = []string{emptyFile}
+	if _, err := s.Connect(initData); err == nil {
+		t.Error("connected with the file without certificates")
+	}
+}
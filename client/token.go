@@ -0,0 +1,238 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys of the token handshake.
+// The client sends login and password with TokenRequestMD,
+// the server returns the token in the header TokenMD of the response,
+// and the client uses it in AuthorizationMD of the next calls.
+// The server must support the handshake, otherwise calls fail with the error
+// instead of sending the password with every call.
+const (
+	// TokenRequestMD asks the server to return a token.
+	TokenRequestMD = "token-request"
+	// TokenMD is the header with the token returned by the server.
+	TokenMD = "token"
+	// TokenExpiryMD is the header with the expiry time of the token in RFC 3339 format.
+	TokenExpiryMD = "token-expiry"
+	// AuthorizationMD carries the token as "Bearer <token>".
+	AuthorizationMD = "authorization"
+)
+
+// tokenRefresh is the time before expiry, when the token is refreshed.
+const tokenRefresh = 30 * time.Second
+
+// registerMethod is the call of registration.
+// Tokens are not issued with it: the user is not known to the server yet.
+const registerMethod = "/api.GoGame/RegisterUser"
+
+// errNoToken is returned by calls accepted with login and password without a token,
+// so the server not supporting tokens is not sent the password with every call silently.
+var errNoToken = status.Error(codes.Unauthenticated, "no token is issued by the server, it may not support token authentication")
+
+// cachedToken is the token saved in the cache file.
+type cachedToken struct {
+	Token  string    `json:"token"`
+	Expiry time.Time `json:"expiry,omitempty"`
+}
+
+// TokenAuthentication exchanges the login/password for a token once
+// and sends the token instead of them with the next calls.
+// The token is refreshed before its expiry or when the server rejects it.
+// It needs the interceptor returned by Interceptor to receive tokens.
+type TokenAuthentication struct {
+	Login    string
+	Password string
+	// Insecure allows to send credentials without TLS.
+	Insecure bool
+	// CacheFile is the file to keep the token between runs, if not empty.
+	CacheFile string
+
+	mu    sync.Mutex
+	token cachedToken
+}
+
+// NewTokenAuthentication returns the TokenAuthentication with the token
+// loaded from cacheFile, if any.
+func NewTokenAuthentication(login, password, cacheFile string, insecure bool) *TokenAuthentication {
+	a := &TokenAuthentication{
+		Login:     login,
+		Password:  password,
+		Insecure:  insecure,
+		CacheFile: cacheFile,
+	}
+	if cacheFile != "" {
+		if data, err := ioutil.ReadFile(cacheFile); err == nil {
+			json.Unmarshal(data, &a.token)
+		}
+	}
+	return a
+}
+
+// TokenCacheFile returns the path to the cache file of the token of the user on the server
+// in the directory dir.
+// The token is kept for the login and password, so it is not used after the password is changed.
+// The name of the file contains a hash of them, not the password itself.
+func TokenCacheFile(dir, login, password, address string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(login + "@" + address)
+	sum := sha256.Sum256([]byte(login + "\x00" + password))
+	return filepath.Join(dir, fmt.Sprintf("%s-%x.json", name, sum[:8]))
+}
+
+// GetRequestMetadata gets the current request metadata.
+func (a *TokenAuthentication) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	if token := a.valid(); token != "" {
+		return map[string]string{AuthorizationMD: "Bearer " + token}, nil
+	}
+	return map[string]string{
+		"login":        a.Login,
+		"password":     a.Password,
+		TokenRequestMD: "true",
+	}, nil
+}
+
+// RequireTransportSecurity indicates whether the credentials requires transport security.
+func (a *TokenAuthentication) RequireTransportSecurity() bool {
+	return !a.Insecure
+}
+
+// valid returns the token, if it is not going to expire soon.
+func (a *TokenAuthentication) valid() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token.Expiry.IsZero() || time.Until(a.token.Expiry) > tokenRefresh {
+		return a.token.Token
+	}
+	return ""
+}
+
+// Interceptor returns the interceptor to receive tokens from responses.
+// The call rejected with the token is repeated once with login and password.
+// The call accepted with login and password fails, if no token is issued with it,
+// except RegisterUser.
+func (a *TokenAuthentication) Interceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		token := a.valid()
+		issued, err := a.invoke(ctx, method, req, reply, cc, invoker, opts...)
+		if token != "" && status.Code(err) == codes.Unauthenticated {
+			a.reject(token)
+			token = ""
+			issued, err = a.invoke(ctx, method, req, reply, cc, invoker, opts...)
+		}
+		if err == nil && token == "" && !issued && method != registerMethod {
+			return errNoToken
+		}
+		return err
+	}
+}
+
+// invoke performs the call and remembers the token from its header, if any.
+// It reports whether the token is issued.
+func (a *TokenAuthentication) invoke(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (issued bool, err error) {
+	var header metadata.MD
+	err = invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header))...)
+	if v := header.Get(TokenMD); len(v) > 0 && v[0] != "" {
+		token := cachedToken{Token: v[0]}
+		if v := header.Get(TokenExpiryMD); len(v) > 0 {
+			token.Expiry, _ = time.Parse(time.RFC3339, v[0])
+		}
+		a.set(token)
+		return true, err
+	}
+	return false, err
+}
+
+// set remembers the token and saves it into the cache.
+func (a *TokenAuthentication) set(token cachedToken) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = token
+	a.save()
+}
+
+// reject forgets the token rejected by the server,
+// unless it is already replaced with another one.
+func (a *TokenAuthentication) reject(token string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token.Token == token {
+		a.token = cachedToken{}
+		a.save()
+	}
+}
+
+// save writes the token into the cache file readable by the user only.
+// The cache is removed, if there is no token.
+// Errors are ignored: without the cache the token is just requested again.
+// a.mu should be held.
+func (a *TokenAuthentication) save() {
+	if a.CacheFile == "" {
+		return
+	}
+	if a.token.Token == "" {
+		os.Remove(a.CacheFile)
+		return
+	}
+	writeTokenCache(a.CacheFile, a.token)
+}
+
+// writeTokenCache writes the token into the file with permissions 0600.
+func writeTokenCache(file string, token cachedToken) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(file), ".token")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	// TempFile creates files with 0600 already, keep it explicit.
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
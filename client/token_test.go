@@ -0,0 +1,189 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/client"
+	"github.com/yagoggame/grpc_client/client/clienttest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// tokenClient connects to the server as alice authenticated with tokens
+// cached in the directory.
+func tokenClient(t *testing.T, s *clienttest.Server, dir, password string) (api.GoGameClient, func() error) {
+	t.Helper()
+	initData := s.IniData("alice", password)
+	initData.Token = true
+	initData.TokenCacheDir = dir
+	conn, err := s.Connect(initData)
+	if err != nil {
+		t.Fatalf("can't connect: %v", err)
+	}
+	return api.NewGoGameClient(conn), conn.Close
+}
+
+// enterAndLeave enters the lobby and leaves it.
+func enterAndLeave(t *testing.T, c api.GoGameClient) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := c.EnterTheLobby(ctx, &api.EmptyMessage{}); err != nil {
+		t.Fatalf("EnterTheLobby: %v", err)
+	}
+	if _, err := c.LeaveTheLobby(ctx, &api.EmptyMessage{}); err != nil {
+		t.Fatalf("LeaveTheLobby: %v", err)
+	}
+}
+
+func TestTokenFlow(t *testing.T) {
+	s := newServer(t)
+	defer s.Close()
+	s.AddUser("alice", "secret")
+	dir, err := ioutil.TempDir("", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the token is issued once and is used by the next calls.
+	c, closeConn := tokenClient(t, s, dir, "secret")
+	enterAndLeave(t, c)
+	if n := s.TokensIssued(); n != 1 {
+		t.Fatalf("tokens issued: got %d, want 1", n)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("token cache files: got %v, %v, want one file", files, err)
+	}
+	info, err := os.Stat(files[0])
+	if err != nil {
+		t.Fatalf("token cache file: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("token cache file: got mode %v, want 0600", perm)
+	}
+	closeConn()
+
+	// the cached token is used by the next run.
+	c, closeConn = tokenClient(t, s, dir, "secret")
+	defer closeConn()
+	enterAndLeave(t, c)
+	if n := s.TokensIssued(); n != 1 {
+		t.Errorf("tokens issued with the cache: got %d, want 1", n)
+	}
+
+	// the rejected token is replaced using login and password.
+	s.RevokeTokens()
+	enterAndLeave(t, c)
+	if n := s.TokensIssued(); n != 2 {
+		t.Errorf("tokens issued after revocation: got %d, want 2", n)
+	}
+
+	// the token is refreshed before its expiry.
+	s.SetTokenTTL(time.Second)
+	s.RevokeTokens()
+	enterAndLeave(t, c)
+	if n := s.TokensIssued(); n != 4 {
+		t.Errorf("tokens issued with short lifetime: got %d, want 4", n)
+	}
+}
+
+func TestTokenWrongPassword(t *testing.T) {
+	s := newServer(t)
+	defer s.Close()
+	s.AddUser("alice", "other")
+	dir, err := ioutil.TempDir("", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c, closeConn := tokenClient(t, s, dir, "secret")
+	defer closeConn()
+	if err := client.RemoveUser(context.Background(), c); !errors.Is(err, client.ErrUnauthenticated) {
+		t.Errorf("RemoveUser with wrong password: got %v, want ErrUnauthenticated", err)
+	}
+	if n := s.TokensIssued(); n != 0 {
+		t.Errorf("tokens issued: got %d, want 0", n)
+	}
+}
+
+func TestTokenNotIssued(t *testing.T) {
+	s := newServer(t)
+	defer s.Close()
+	s.DisableTokens()
+	dir, err := ioutil.TempDir("", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c, closeConn := tokenClient(t, s, dir, "secret")
+	defer closeConn()
+	// tokens are not issued for registration.
+	if err := client.RegisterUser(context.Background(), c); err != nil {
+		t.Fatalf("RegisterUser: %v", err)
+	}
+	err = client.RemoveUser(context.Background(), c)
+	var stErr *client.StatusError
+	if !errors.Is(err, client.ErrUnauthenticated) || !errors.As(err, &stErr) || !strings.Contains(stErr.Message, "no token") {
+		t.Errorf("RemoveUser without a token: got %v, want ErrUnauthenticated without a token", err)
+	}
+}
+
+func TestTokenCacheOfPassword(t *testing.T) {
+	s := newServer(t)
+	defer s.Close()
+	s.AddUser("alice", "secret")
+	dir, err := ioutil.TempDir("", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c, closeConn := tokenClient(t, s, dir, "secret")
+	enterAndLeave(t, c)
+	closeConn()
+
+	// the token of the right password is not used with the wrong one.
+	c, closeConn = tokenClient(t, s, dir, "wrong")
+	defer closeConn()
+	if _, err := c.EnterTheLobby(context.Background(), &api.EmptyMessage{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("EnterTheLobby with the wrong password: got %v, want ErrUnauthenticated", err)
+	}
+
+	file := client.TokenCacheFile(dir, "alice", "secret", "localhost:7777")
+	if file == client.TokenCacheFile(dir, "alice", "wrong", "localhost:7777") {
+		t.Error("tokens of different passwords are cached in the same file")
+	}
+	if strings.Contains(file, "secret") {
+		t.Errorf("the password is a part of the file name %q", file)
+	}
+	if _, err := os.Stat(file); err != nil {
+		t.Errorf("the token is not cached: %v", err)
+	}
+}
@@ -0,0 +1,293 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+package cmd
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/client"
+	"gopkg.in/yaml.v3"
+)
+
+// account is the login and password of the user to be registered.
+type account struct {
+	Login    string `yaml:"login"`
+	Password string `yaml:"password"`
+	// line is the line of the file the account is given at.
+	line int
+}
+
+// importResult is the result of registration of the account.
+type importResult struct {
+	account
+	err error
+}
+
+// accountsCmd represents the accounts command
+var accountsCmd = &cobra.Command{
+	Use:   "accounts",
+	Short: "manage accounts of several users",
+}
+
+var accountsImportCmd = &cobra.Command{
+	Use:   "import file",
+	Short: "register users from the file",
+	Long: `register users listed in the CSV or YAML file on the service.
+The CSV file has rows "login,password" on separate lines, the header row is optional,
+fields with commas, quotes, line breaks or leading spaces are quoted.
+The YAML file is a list of maps with login and password keys.
+The password may be omitted for users saved with the login command.
+Every user is registered with its own connection.
+Results are reported with lines of the file, one event per user with --output json.`,
+	Args: cobra.ExactArgs(1),
+	RunE: accountsImportCmdFnc,
+}
+
+func init() {
+	rootCmd.AddCommand(accountsCmd)
+	accountsCmd.AddCommand(accountsImportCmd)
+}
+
+// readAccounts reads accounts from the file in the format given by its extension.
+func readAccounts(name string) ([]account, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		return readYAMLAccounts(f)
+	case ".csv", ".txt", "":
+		return readCSVAccounts(f)
+	}
+	return nil, fmt.Errorf("unknown format of %s, should be CSV or YAML", name)
+}
+
+// readCSVAccounts reads accounts from rows "login,password".
+// Every row is parsed separately to know its line,
+// a row is continued on the next line while a quoted field is open.
+// Empty lines and comments are skipped, as well as the header row, if any.
+// Spaces are kept, they may be a part of the password.
+func readCSVAccounts(r io.Reader) ([]account, error) {
+	var (
+		accounts []account
+		row      strings.Builder
+		start    int
+	)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if row.Len() == 0 {
+			if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+			start = line
+		}
+		row.WriteString(text)
+		row.WriteByte('\n')
+		// quotes are paired, unless the quoted field goes on.
+		if strings.Count(row.String(), `"`)%2 != 0 {
+			continue
+		}
+
+		record, err := readCSVRow(row.String(), start)
+		if err != nil {
+			return nil, err
+		}
+		row.Reset()
+		if len(accounts) == 0 && strings.EqualFold(record[0], "login") && strings.EqualFold(record[1], "password") {
+			continue
+		}
+		accounts = append(accounts, account{Login: record[0], Password: record[1], line: start})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if row.Len() > 0 {
+		// the quoted field is not closed.
+		_, err := readCSVRow(row.String(), start)
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// readCSVRow parses the row starting at the line.
+// Lines of errors are counted from the beginning of the file.
+func readCSVRow(row string, start int) ([]string, error) {
+	reader := csv.NewReader(strings.NewReader(row))
+	reader.FieldsPerRecord = 2
+	record, err := reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			parseErr.StartLine += start - 1
+			parseErr.Line += start - 1
+		}
+		return nil, err
+	}
+	return record, nil
+}
+
+// readYAMLAccounts reads accounts from the list of maps with login and password keys.
+func readYAMLAccounts(r io.Reader) ([]account, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, err
+	}
+	list := &doc
+	if list.Kind == yaml.DocumentNode && len(list.Content) > 0 {
+		list = list.Content[0]
+	}
+	if list.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("line %d: list of accounts expected", list.Line)
+	}
+
+	accounts := make([]account, len(list.Content))
+	for i, item := range list.Content {
+		if err := checkAccountKeys(item); err != nil {
+			return nil, err
+		}
+		if err := item.Decode(&accounts[i]); err != nil {
+			return nil, err
+		}
+		accounts[i].line = item.Line
+	}
+	return accounts, nil
+}
+
+// checkAccountKeys rejects unknown keys of the account.
+func checkAccountKeys(item *yaml.Node) error {
+	if item.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: map with login and password expected", item.Line)
+	}
+	for i := 0; i < len(item.Content); i += 2 {
+		switch key := item.Content[i]; key.Value {
+		case "login", "password":
+		default:
+			return fmt.Errorf("line %d: unknown key %q", key.Line, key.Value)
+		}
+	}
+	return nil
+}
+
+// registerAccount registers the account with its own connection.
+// The password saved with the login command is used, if it is not given.
+func registerAccount(initData client.IniDataContainer, acc account) (err error) {
+	initData.Login = acc.Login
+	initData.Password = acc.Password
+	if initData.Login != "" && initData.Password == "" {
+		if initData.Password, err = storedPassword(&initData); err != nil {
+			return err
+		}
+	}
+	if initData.Login == "" || initData.Password == "" {
+		return errors.New("login and password should be specified")
+	}
+
+	conn, err := connect(&initData)
+	if err != nil {
+		return fmt.Errorf("connection: %w", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := callContext()
+	defer cancel()
+	return client.RegisterUser(ctx, api.NewGoGameClient(conn))
+}
+
+// printImportResults prints results of the import as a table.
+func printImportResults(w io.Writer, results []importResult, failed int) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "LINE\tLOGIN\tRESULT\tERROR")
+	for _, r := range results {
+		result, msg := "registered", ""
+		if r.err != nil {
+			result, msg = "failed", r.err.Error()
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", r.line, r.Login, result, msg)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "Registered: %d, failed: %d\n", len(results)-failed, failed)
+	return err
+}
+
+// emitImportResult reports the result of registration of the account with the event.
+func emitImportResult(r importResult) {
+	ev := client.Event{Event: client.EventDone, Op: "RegisterUser", Login: r.Login, Line: r.line}
+	if r.err != nil {
+		ev.Event = client.EventError
+		ev.Error = client.NewErrorInfo(r.err)
+	}
+	emit(ev)
+}
+
+func accountsImportCmdFnc(cmd *cobra.Command, args []string) error {
+	accounts, err := readAccounts(args[0])
+	if err != nil {
+		return fmt.Errorf("can't read accounts: %w", err)
+	}
+	initData := new(client.IniDataContainer)
+	readConnectionData(initData)
+	if err := checkInsecure(initData, cmd); err != nil {
+		return err
+	}
+
+	if reportDryRun("would register %d users from %s on %s:%d", len(accounts), args[0], initData.IP, initData.Port) {
+		return nil
+	}
+	if err := confirm(fmt.Sprintf("do you realy want to register %d users on service?", len(accounts))); err != nil {
+		return err
+	}
+
+	results := make([]importResult, 0, len(accounts))
+	failed := 0
+	for _, acc := range accounts {
+		result := importResult{account: acc, err: registerAccount(*initData, acc)}
+		if result.err != nil {
+			failed++
+		}
+		emitImportResult(result)
+		results = append(results, result)
+	}
+
+	if !jsonOutput() {
+		if err := printImportResults(os.Stdout, results, failed); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d users are not registered", failed, len(accounts))
+	}
+	return nil
+}
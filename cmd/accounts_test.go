@@ -0,0 +1,151 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"encoding/csv"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yagoggame/grpc_client/client"
+)
+
+func TestReadCSVAccounts(t *testing.T) {
+	accounts, err := readCSVAccounts(strings.NewReader("# users of the club\n\nlogin,password\nalice,secret\n\nbob,other\n"))
+	if err != nil {
+		t.Fatalf("readCSVAccounts: %v", err)
+	}
+	want := []account{{"alice", "secret", 4}, {"bob", "other", 6}}
+	if !reflect.DeepEqual(accounts, want) {
+		t.Errorf("got %v, want %v", accounts, want)
+	}
+
+	_, err = readCSVAccounts(strings.NewReader("alice,secret\n\nbob\n"))
+	var parseErr *csv.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Line != 3 {
+		t.Errorf("wrong row: got %v, want the error at line 3", err)
+	}
+}
+
+func TestReadCSVAccountsQuoted(t *testing.T) {
+	input := "alice,\"two\nlines\"\n\n\"bob\",\" spaces, \"\"quotes\"\"\"\ncarol, secret\n"
+	accounts, err := readCSVAccounts(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("readCSVAccounts: %v", err)
+	}
+	want := []account{
+		{"alice", "two\nlines", 1},
+		{"bob", ` spaces, "quotes"`, 4},
+		// the password is not trimmed.
+		{"carol", " secret", 5},
+	}
+	if !reflect.DeepEqual(accounts, want) {
+		t.Errorf("got %q, want %q", accounts, want)
+	}
+
+	// the row is not finished, the error is at the last line.
+	_, err = readCSVAccounts(strings.NewReader("alice,secret\nbob,\"open\n\nquote\n"))
+	var parseErr *csv.ParseError
+	if !errors.As(err, &parseErr) || parseErr.StartLine != 2 || parseErr.Line != 4 {
+		t.Errorf("unclosed quote: got %v, want the error of the row from line 2", err)
+	}
+	_, err = readCSVAccounts(strings.NewReader("alice,secret\n\"bob\",\"two\nlines\",extra\n"))
+	if !errors.As(err, &parseErr) || parseErr.StartLine != 2 {
+		t.Errorf("wrong multi-line row: got %v, want the error of the row from line 2", err)
+	}
+}
+
+func TestReadYAMLAccounts(t *testing.T) {
+	accounts, err := readYAMLAccounts(strings.NewReader("# users of the club\n- login: alice\n  password: secret\n\n- login: bob\n"))
+	if err != nil {
+		t.Fatalf("readYAMLAccounts: %v", err)
+	}
+	want := []account{{"alice", "secret", 2}, {"bob", "", 5}}
+	if len(accounts) != len(want) || accounts[0] != want[0] || accounts[1] != want[1] {
+		t.Errorf("got %v, want %v", accounts, want)
+	}
+
+	_, err = readYAMLAccounts(strings.NewReader("- login: alice\n  pasword: secret\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("unknown key: got %v, want the error at line 2", err)
+	}
+}
+
+func TestAccountsImport(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+	buf, restore := recordEvents()
+	defer restore()
+	env.server.AddUser("carol", "secret")
+	file := env.writeFile("users.csv", "login,password\nalice,secret\n\n# carol is already registered\ncarol,secret\n")
+
+	err := env.execute("accounts", "import", file, "--output", "json")
+	if err == nil {
+		t.Error("import of the registered user: error expected")
+	}
+	if _, ok := env.server.Password("alice"); !ok {
+		t.Error("alice is not registered")
+	}
+
+	got := buf.events(t)
+	if len(got) != 2 {
+		t.Fatalf("got %d events, want one per account: %v", len(got), got)
+	}
+	if ev := got[0]; ev.Event != client.EventDone || ev.Login != "alice" || ev.Line != 2 {
+		t.Errorf("event of alice: got %+v", ev)
+	}
+	if ev := got[1]; ev.Event != client.EventError || ev.Login != "carol" || ev.Line != 5 || ev.Error == nil {
+		t.Errorf("event of carol: got %+v", ev)
+	}
+}
+
+func TestAccountsImportInsecureWithCertificate(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+	defer rootCmd.PersistentFlags().Set("insecure", "false")
+	file := env.writeFile("users.csv", "alice,secret\n")
+
+	err := env.execute("accounts", "import", file, "--insecure")
+	if exitCode(err) != exitUsage {
+		t.Errorf("got %v, want the usage error", err)
+	}
+	if _, ok := env.server.Password("alice"); ok {
+		t.Error("alice is registered")
+	}
+}
+
+func TestAccountsImportStoredPassword(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+	os.Setenv("GRPC_CLIENT_STORE_PASSPHRASE", "passphrase")
+	defer os.Unsetenv("GRPC_CLIENT_STORE_PASSPHRASE")
+
+	if err := env.execute("login", "-l", "bob", "-p", "saved"); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	file := env.writeFile("users.yaml", "- login: bob\n")
+	if err := env.execute("accounts", "import", file); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if password, ok := env.server.Password("bob"); !ok || password != "saved" {
+		t.Errorf("bob is registered with %q, %t, want the saved password", password, ok)
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yagoggame/grpc_client/client"
+	"github.com/yagoggame/grpc_client/gtp"
+)
+
+// Engines of the bot.
+const (
+	engineRandom = "random"
+	engineGTP    = "gtp"
+)
+
+// botCmd represents the bot command
+var botCmd = &cobra.Command{
+	Use:   "bot",
+	Short: "play games with the engine instead of the user",
+	Long: `play games with the engine instead of the user without any input.
+The bot joins games one after another and makes turns chosen by the engine:
+  random - random legal moves, e.g. to soak-test the server or to practice against;
+  gtp    - moves of the external engine speaking the Go Text Protocol, e.g.
+           grpc_client bot --engine gtp --gtp-command "gnugo --mode gtp --level 5"`,
+	Args: cobra.NoArgs,
+	RunE: botCmdFnc,
+}
+
+func init() {
+	rootCmd.AddCommand(botCmd)
+	botCmd.Flags().String("engine", engineRandom, "engine choosing moves: random or gtp")
+	botCmd.Flags().String("gtp-command", "", "command line of the GTP engine, arguments are separated with spaces")
+	botCmd.Flags().Duration("gtp-timeout", gtp.DefaultTimeout, "time limit of the response of the GTP engine to a command, 0 means no limit")
+	botCmd.Flags().Int("games", 1, "number of games to play, 0 means no limit")
+	botCmd.Flags().Int64("seed", 0, "seed of random moves, the current time is used if 0")
+}
+
+// newEngine returns the engine selected with flags of the command.
+func newEngine(cmd *cobra.Command) (client.MoveEngine, error) {
+	name, _ := cmd.Flags().GetString("engine")
+	switch name {
+	case engineRandom:
+		seed, _ := cmd.Flags().GetInt64("seed")
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		return client.NewRandomEngine(seed), nil
+	case engineGTP:
+		command, _ := cmd.Flags().GetString("gtp-command")
+		if command == "" {
+			return nil, newUsageError(cmd, "the command of the GTP engine should be specified with --gtp-command.")
+		}
+		timeout, _ := cmd.Flags().GetDuration("gtp-timeout")
+		engine, err := gtp.Start(command, timeout, os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		return engine, nil
+	}
+	return nil, newUsageError(cmd, fmt.Sprintf("unknown engine %q.", name))
+}
+
+func botCmdFnc(cmd *cobra.Command, args []string) error {
+	initData := new(client.IniDataContainer)
+	if err := iniFromViper(initData, cmd); err != nil {
+		return err
+	}
+	engine, err := newEngine(cmd)
+	if err != nil {
+		return err
+	}
+	if closer, ok := engine.(io.Closer); ok {
+		defer closer.Close()
+	}
+	games, _ := cmd.Flags().GetInt("games")
+	if games < 0 {
+		return newUsageError(cmd, "number of games can't be negative.")
+	}
+
+	c, opts, closeConn, err := gameConnection(initData, false)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	return client.BotFlow(c, engine, games, messages(), client.HandleSignals(), opts...)
+}
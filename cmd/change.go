@@ -18,57 +18,110 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package cmd
 
 import (
-	"bufio"
-	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
-	"os"
-	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/yagoggame/api"
 	"github.com/yagoggame/grpc_client/client"
+	"github.com/yagoggame/grpc_client/credstore"
 )
 
 // changeCmd represents the change command
 var changeCmd = &cobra.Command{
-	Use:   "change newLogin newPassword",
+	Use:   "change newLogin",
 	Short: "change user's requisites on service",
-	Long:  `change user's requisites (login and password) on service's data base`,
-	Run:   changeCmdFnc,
-	Args:  cobra.ExactArgs(2),
+	Long: `change user's requisites (login and password) on service's data base.
+The new password is asked twice or read from the file given with --new-password-file.`,
+	RunE: changeCmdFnc,
+	Args: cobra.ExactArgs(1),
 }
 
 func init() {
 	rootCmd.AddCommand(changeCmd)
+	changeCmd.Flags().String("new-password-file", "", "file with the new password")
 }
 
-func changeCmdFnc(cmd *cobra.Command, args []string) {
+func changeCmdFnc(cmd *cobra.Command, args []string) error {
 	initData := new(client.IniDataContainer)
-	iniFromViper(initData, cmd)
+	if err := iniFromViper(initData, cmd); err != nil {
+		return err
+	}
 	newLogin := args[0]
-	newPassword := args[1]
+	newPassword, err := readNewPassword(cmd)
+	if err != nil {
+		return err
+	}
+	if newPassword == "" {
+		return newUsageError(cmd, "new password should be specified.")
+	}
 
-	fmt.Printf("do you realy want to change %q user requisites on service?\ntype \"yes\" if you do.\n", initData.Login)
-	scanner := bufio.NewScanner(os.Stdin)
-	if !scanner.Scan() {
-		log.Fatal("Canceled")
+	if reportDryRun("would change %q user requisites to %q on %s:%d", initData.Login, newLogin, initData.IP, initData.Port) {
+		return nil
 	}
-	if txt := scanner.Text(); strings.Compare(txt, "yes") != 0 {
-		log.Fatal("Canceled")
+	if err := confirm(fmt.Sprintf("do you realy want to change %q user requisites on service?", initData.Login)); err != nil {
+		return err
 	}
 
-	conn, err := client.Connect(initData)
+	conn, err := connect(initData)
 	if err != nil {
-		log.Fatalf("connection: %s", err)
+		return fmt.Errorf("connection: %w", err)
 	}
 	defer conn.Close()
+	reportConnection(initData)
 
 	c := api.NewGoGameClient(conn)
 
-	_, err = c.ChangeUserRequisits(context.Background(), &api.RequisitsMessage{Login: newLogin, Password: newPassword})
+	ctx, cancel := callContext()
+	defer cancel()
+	if err := client.ChangeUserRequisits(ctx, c, newLogin, newPassword); err != nil {
+		return err
+	}
+	reportDone("ChangeUserRequisits", initData.Login)
+
+	if err := updateStoredPassword(initData, newLogin, newPassword); err != nil {
+		log.Printf("Saved credentials are not updated: %s", err)
+	}
+	return nil
+}
+
+// readNewPassword returns the new password from the file given with --new-password-file
+// or asks it from the user.
+func readNewPassword(cmd *cobra.Command) (string, error) {
+	if file, _ := cmd.Flags().GetString("new-password-file"); file != "" {
+		return passwordFromFile(file)
+	}
+	password, err := askNewPassword()
+	switch {
+	case err == io.EOF, err == nil:
+		return password, nil
+	case errors.Is(err, errPasswordMismatch):
+		return "", err
+	}
+	return "", fmt.Errorf("can't read the new password: %w", err)
+}
+
+// updateStoredPassword replaces saved credentials of the user, if any, with new ones.
+func updateStoredPassword(initData *client.IniDataContainer, newLogin, newPassword string) error {
+	store, err := openStore()
 	if err != nil {
-		log.Fatalf("ChangeUserRequisits: %s", err)
+		return err
+	}
+	oldKey := storeKey(initData)
+	_, err = store.Get(oldKey)
+	switch {
+	case errors.Is(err, credstore.ErrNotFound):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	if err := store.Delete(oldKey); err != nil {
+		return err
 	}
-	log.Print("Done")
+	newData := *initData
+	newData.Login = newLogin
+	return store.Set(storeKey(&newData), newPassword)
 }
@@ -0,0 +1,342 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/yagoggame/grpc_client/client"
+	"github.com/yagoggame/grpc_client/client/clienttest"
+)
+
+// testEnv is the stand-in server with the commands connected to it.
+type testEnv struct {
+	t      *testing.T
+	server *clienttest.Server
+	dir    string
+}
+
+// newTestEnv starts the server and makes the commands connect to it.
+// Files of the commands are kept in the temporary directory.
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	return startTestEnv(t, clienttest.NewServer)
+}
+
+// startTestEnv is newTestEnv with the server started by newServer,
+// e.g. clienttest.NewInsecureServer.
+func startTestEnv(t *testing.T, newServer func(size int, komi float64) (*clienttest.Server, error)) *testEnv {
+	t.Helper()
+	s, err := newServer(5, 0.5)
+	if err != nil {
+		t.Fatalf("can't start the server: %v", err)
+	}
+	dir, err := ioutil.TempDir("", "grpc_client")
+	if err != nil {
+		s.Close()
+		t.Fatalf("can't create temporary dir: %v", err)
+	}
+	connect = s.Connect
+	e := &testEnv{t: t, server: s, dir: dir}
+	// viper keeps settings of the previous config file, if the file is not found.
+	e.writeFile("config.yaml", "")
+	return e
+}
+
+// close stops the server and restores the connection of the commands.
+func (e *testEnv) close() {
+	connect = client.Connect
+	e.server.Close()
+	os.RemoveAll(e.dir)
+}
+
+// execute runs the command line with the settings of the server.
+// Actions are confirmed with --yes.
+func (e *testEnv) execute(args ...string) error {
+	return e.ask(append(args, "--yes")...)
+}
+
+// ask runs the command line like execute, but actions are confirmed by the user.
+func (e *testEnv) ask(args ...string) error {
+	assumeYes = false
+	args = append(args,
+		"--config", filepath.Join(e.dir, "config.yaml"),
+		"--address", "localhost",
+		"--cert", e.server.CertFile,
+		"--store", storeFile,
+		"--store-file", filepath.Join(e.dir, "credentials"),
+		"--token-cache", "",
+	)
+	rootCmd.SetArgs(args)
+	return rootCmd.Execute()
+}
+
+// config executes the command line with the config file of the environment only,
+// other settings are reset before. The standard output is returned.
+func (e *testEnv) config(args ...string) (string, error) {
+	e.t.Helper()
+	resetSettings()
+	rootCmd.SetArgs(append(args, "--config", filepath.Join(e.dir, "config.yaml")))
+	var err error
+	out := capture(e.t, &os.Stdout, func() {
+		err = rootCmd.Execute()
+	})
+	return out, err
+}
+
+// resetSettings restores flags of all commands and drops values of the profile,
+// so settings of previous commands don't affect the next one.
+func resetSettings() {
+	reset := func(flag *pflag.Flag) {
+		if !flag.Changed {
+			return
+		}
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			slice.Replace(nil)
+		} else {
+			flag.Value.Set(flag.DefValue)
+		}
+		flag.Changed = false
+	}
+	var walk func(*cobra.Command)
+	walk = func(command *cobra.Command) {
+		command.Flags().VisitAll(reset)
+		command.PersistentFlags().VisitAll(reset)
+		for _, sub := range command.Commands() {
+			walk(sub)
+		}
+	}
+	walk(rootCmd)
+
+	for key := range profileApplied {
+		viper.Set(key, nil)
+		delete(profileApplied, key)
+	}
+}
+
+// writeFile writes the file in the temporary directory and returns its path.
+func (e *testEnv) writeFile(name, content string) string {
+	e.t.Helper()
+	path := filepath.Join(e.dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		e.t.Fatalf("can't write %s: %v", name, err)
+	}
+	return path
+}
+
+// capture returns the output written by fn to the file, e.g. os.Stdout.
+func capture(t *testing.T, file **os.File, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := *file
+	*file = w
+	defer func() { *file = saved }()
+
+	out := make(chan string)
+	go func() {
+		data, _ := ioutil.ReadAll(r)
+		out <- string(data)
+	}()
+	fn()
+	w.Close()
+	return <-out
+}
+
+// withStdin runs fn with the standard input reading the input from the file,
+// so it is not a terminal.
+func withStdin(t *testing.T, input string, fn func()) {
+	t.Helper()
+	f, err := ioutil.TempFile("", "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err := f.WriteString(input); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stdin
+	os.Stdin = f
+	defer func() { os.Stdin = saved }()
+	fn()
+}
+
+func TestAccountCommands(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+
+	if err := env.execute("register", "-l", "alice", "-p", "secret"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if password, ok := env.server.Password("alice"); !ok || password != "secret" {
+		t.Fatalf("the user is not registered: %q, %t", password, ok)
+	}
+
+	newPassword := env.writeFile("new-password", "newsecret\n")
+	if err := env.execute("change", "bob", "-l", "alice", "-p", "secret", "--new-password-file", newPassword); err != nil {
+		t.Fatalf("change: %v", err)
+	}
+	if password, ok := env.server.Password("bob"); !ok || password != "newsecret" {
+		t.Fatalf("requisits are not changed: %q, %t", password, ok)
+	}
+
+	err := env.execute("remove", "-l", "alice", "-p", "secret")
+	if !errors.Is(err, client.ErrUnauthenticated) || exitCode(err) != exitUnauthenticated {
+		t.Errorf("remove with old requisits: got %v, want ErrUnauthenticated", err)
+	}
+	if err := env.execute("remove", "-l", "bob", "-p", "newsecret"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if _, ok := env.server.Password("bob"); ok {
+		t.Error("the user is not removed")
+	}
+}
+
+func TestInsecureConnection(t *testing.T) {
+	env := startTestEnv(t, clienttest.NewInsecureServer)
+	defer env.close()
+
+	var err error
+	stderr := capture(t, &os.Stderr, func() {
+		err = env.execute("register", "--insecure", "-l", "alice", "-p", "secret")
+	})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if _, ok := env.server.Password("alice"); !ok {
+		t.Error("the user is not registered")
+	}
+	if !strings.Contains(stderr, insecureWarning) {
+		t.Errorf("the warning is not printed to stderr: %q", stderr)
+	}
+
+	// TLS is not negotiated with the server.
+	rootCmd.PersistentFlags().Set("insecure", "false")
+	if err := env.execute("remove", "-l", "alice", "-p", "secret"); !errors.Is(err, client.ErrUnavailable) {
+		t.Errorf("remove with TLS: got %v, want ErrUnavailable", err)
+	}
+}
+
+func TestConfirmFromInput(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+
+	var err error
+	withStdin(t, "yes\n", func() {
+		err = env.ask("register", "-l", "alice", "-p", "secret")
+	})
+	if err != nil {
+		t.Fatalf("register confirmed with the input: %v", err)
+	}
+	if _, ok := env.server.Password("alice"); !ok {
+		t.Error("the user is not registered")
+	}
+
+	withStdin(t, "no\n", func() {
+		err = env.ask("remove", "-l", "alice", "-p", "secret")
+	})
+	if !errors.Is(err, errCanceled) {
+		t.Errorf("remove not confirmed: got %v, want errCanceled", err)
+	}
+	withStdin(t, "", func() {
+		err = env.ask("remove", "-l", "alice", "-p", "secret")
+	})
+	if !errors.Is(err, errCanceled) || !strings.Contains(err.Error(), "--yes") {
+		t.Errorf("remove without the answer: got %v, want errCanceled with the hint", err)
+	}
+	if _, ok := env.server.Password("alice"); !ok {
+		t.Error("the user is removed without confirmation")
+	}
+}
+
+func TestDryRunDoesNotCallServer(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+	defer func() { dryRun = false }()
+
+	if err := env.execute("register", "-l", "alice", "-p", "secret", "--dry-run"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if _, ok := env.server.Password("alice"); ok {
+		t.Error("the user is registered in dry run")
+	}
+}
+
+func TestLoginStoresPassword(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+	env.server.AddUser("alice", "secret")
+	os.Setenv("GRPC_CLIENT_STORE_PASSPHRASE", "passphrase")
+	defer os.Unsetenv("GRPC_CLIENT_STORE_PASSPHRASE")
+	if err := env.execute("login", "-l", "alice", "-p", "secret"); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	rootCmd.PersistentFlags().Set("password", "")
+
+	// the password is taken from the store.
+	if err := env.execute("remove", "-l", "alice"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if _, ok := env.server.Password("alice"); ok {
+		t.Error("the user is not removed")
+	}
+}
+
+func TestStoredPasswordFallback(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+	env.server.AddUser("alice", "secret")
+	os.Setenv("GRPC_CLIENT_STORE_PASSPHRASE", "passphrase")
+	defer os.Unsetenv("GRPC_CLIENT_STORE_PASSPHRASE")
+	if err := env.execute("login", "-l", "alice", "-p", "secret"); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	rootCmd.PersistentFlags().Set("password", "")
+
+	// the password is asked, when the store can't be read.
+	os.Setenv("GRPC_CLIENT_STORE_PASSPHRASE", "wrong")
+	var err error
+	withStdin(t, "secret\n", func() {
+		stderr := capture(t, &os.Stderr, func() {
+			err = env.execute("remove", "-l", "alice")
+		})
+		if !strings.Contains(stderr, "wrong passphrase") {
+			t.Errorf("the error of the store is not reported: %q", stderr)
+		}
+	})
+	if err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if _, ok := env.server.Password("alice"); ok {
+		t.Error("the user is not removed")
+	}
+}
@@ -0,0 +1,305 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Sources of values of settings.
+const (
+	sourceFlag    = "flag"
+	sourceEnv     = "env"
+	sourceProfile = "profile"
+	sourceConfig  = "config"
+	sourceDefault = "default"
+)
+
+// secretKeys are the keys with values to be masked.
+var secretKeys = map[string]bool{
+	"password":         true,
+	"store-passphrase": true,
+}
+
+// fileOnlyKeys are the keys without flags, given in the config file or environment only.
+var fileOnlyKeys = []string{"store-passphrase"}
+
+// runOnlyFlags are the flags of a single run, they are not settings.
+var runOnlyFlags = map[string]bool{
+	"config":  true,
+	"help":    true,
+	"yes":     true,
+	"dry-run": true,
+}
+
+// profileApplied are the keys with values from the current profile.
+var profileApplied = make(map[string]bool)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "inspect and edit settings",
+	Long: `inspect effective settings merged from flags, environment, the profile and the config file,
+and edit the config file.`,
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "show effective settings with their sources",
+	Args:  cobra.NoArgs,
+	RunE:  configShowCmdFnc,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get key",
+	Short: "print the effective value of the setting",
+	Args:  cobra.ExactArgs(1),
+	RunE:  configGetCmdFnc,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set key value",
+	Short: "save the value of the setting in the config file",
+	Args:  cobra.ExactArgs(2),
+	RunE:  configSetCmdFnc,
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "create the config file with default settings",
+	Args:  cobra.NoArgs,
+	RunE:  configInitCmdFnc,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configShowCmd, configGetCmd, configSetCmd, configInitCmd)
+	configShowCmd.Flags().Bool("show-secrets", false, "show passwords instead of masking them")
+	configGetCmd.Flags().Bool("show-secrets", false, "show passwords instead of masking them")
+	configInitCmd.Flags().Bool("force", false, "overwrite the existing config file")
+}
+
+// settingFlag returns the flag of the setting, or nil if there is no such.
+func settingFlag(key string) *pflag.Flag {
+	if flag := rootCmd.PersistentFlags().Lookup(key); flag != nil {
+		return flag
+	}
+	return rootCmd.Flags().Lookup(key)
+}
+
+// settingKeys returns sorted keys of all settings.
+func settingKeys() []string {
+	keys := append([]string(nil), fileOnlyKeys...)
+	seen := make(map[string]bool)
+	// persistent flags may be merged into local ones.
+	visit := func(flag *pflag.Flag) {
+		if !runOnlyFlags[flag.Name] && !seen[flag.Name] {
+			seen[flag.Name] = true
+			keys = append(keys, flag.Name)
+		}
+	}
+	rootCmd.PersistentFlags().VisitAll(visit)
+	rootCmd.Flags().VisitAll(visit)
+	sort.Strings(keys)
+	return keys
+}
+
+// knownSetting reports whether there is the setting with the key.
+func knownSetting(key string) bool {
+	for _, known := range settingKeys() {
+		if known == key {
+			return true
+		}
+	}
+	return false
+}
+
+// envName returns the name of the environment variable of the setting.
+func envName(key string) string {
+	return envPrefix + "_" + strings.ToUpper(strings.Replace(key, "-", "_", -1))
+}
+
+// valueSource returns where the effective value of the setting comes from.
+func valueSource(key string) string {
+	if flag := settingFlag(key); flag != nil && flag.Changed {
+		return sourceFlag
+	}
+	// viper ignores empty variables.
+	if os.Getenv(envName(key)) != "" {
+		return sourceEnv
+	}
+	if profileApplied[key] {
+		return fmt.Sprintf("%s %s", sourceProfile, viper.GetString("profile"))
+	}
+	if viper.InConfig(key) {
+		return sourceConfig
+	}
+	return sourceDefault
+}
+
+// settingValue returns the effective value of the setting to be shown.
+func settingValue(key string, showSecrets bool) string {
+	var value string
+	switch v := viper.Get(key).(type) {
+	case nil:
+	case []string:
+		value = strings.Join(v, ",")
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		value = strings.Join(items, ",")
+	default:
+		value = fmt.Sprint(v)
+	}
+	if secretKeys[key] && value != "" && !showSecrets {
+		return "********"
+	}
+	return value
+}
+
+func configShowCmdFnc(cmd *cobra.Command, args []string) error {
+	showSecrets, _ := cmd.Flags().GetBool("show-secrets")
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Config file: %s\n", path)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+	for _, key := range settingKeys() {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", key, settingValue(key, showSecrets), valueSource(key))
+	}
+	return w.Flush()
+}
+
+func configGetCmdFnc(cmd *cobra.Command, args []string) error {
+	key := strings.ToLower(args[0])
+	if !knownSetting(key) {
+		return newUsageError(cmd, fmt.Sprintf("unknown setting %q.", args[0]))
+	}
+	showSecrets, _ := cmd.Flags().GetBool("show-secrets")
+	fmt.Println(settingValue(key, showSecrets))
+	return nil
+}
+
+// parseSetting converts the value of the setting to the type of its flag.
+func parseSetting(key, value string) (interface{}, error) {
+	flag := settingFlag(key)
+	if flag == nil {
+		return value, nil
+	}
+	switch flag.Value.Type() {
+	case "int":
+		return strconv.Atoi(value)
+	case "bool":
+		return strconv.ParseBool(value)
+	case "stringSlice":
+		if value == "" {
+			return []string{}, nil
+		}
+		return strings.Split(value, ","), nil
+	case "duration":
+		// keep it readable in the file.
+		if _, err := time.ParseDuration(value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+	return value, nil
+}
+
+func configSetCmdFnc(cmd *cobra.Command, args []string) error {
+	key := strings.ToLower(args[0])
+	if !knownSetting(key) {
+		return newUsageError(cmd, fmt.Sprintf("unknown setting %q.", args[0]))
+	}
+	value, err := parseSetting(key, args[1])
+	if err != nil {
+		return newUsageError(cmd, fmt.Sprintf("wrong value of %s: %s.", key, err))
+	}
+
+	v, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+	v.Set(key, value)
+	if err := saveConfigFile(v); err != nil {
+		return err
+	}
+	fmt.Printf("%s is saved in %s\n", key, v.ConfigFileUsed())
+	if source := valueSource(key); source != sourceConfig && source != sourceDefault {
+		fmt.Printf("Note: it is overridden now by the %s\n", source)
+	}
+	return nil
+}
+
+// configTemplate is the content of the new config file.
+const configTemplate = `# Config of grpc_client, see grpc_client config show for all settings.
+
+# grpc_server to connect to.
+address: localhost
+port: 7777
+# file with the certificate of grpc_server, the system pool is used if empty.
+cert: ""
+
+# login to use, the password is better saved with grpc_client login.
+login: ""
+
+# time limits of calls and of waiting for another player, 0 means no limit.
+timeout: 10s
+wait-timeout: 0s
+
+# profiles of servers, see grpc_client profile.
+# profile: staging
+# profiles:
+#   staging:
+#     address: staging.example.com
+#     port: 7777
+#     cert: staging.crt
+#     login: me
+`
+
+func configInitCmdFnc(cmd *cobra.Command, args []string) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	force, _ := cmd.Flags().GetBool("force")
+	if _, err := os.Stat(path); err == nil && !force {
+		return newUsageError(cmd, fmt.Sprintf("config file %s already exists, use --force to overwrite it.", path))
+	}
+	if err := ioutil.WriteFile(path, []byte(configTemplate), 0600); err != nil {
+		return fmt.Errorf("can't write config file: %w", err)
+	}
+	fmt.Printf("Config file %s is created\n", path)
+	return nil
+}
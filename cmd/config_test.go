@@ -0,0 +1,234 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSettingKeys(t *testing.T) {
+	for _, key := range []string{"login", "output", "record-dir", "store-passphrase"} {
+		if !knownSetting(key) {
+			t.Errorf("setting %q is unknown", key)
+		}
+	}
+	// flags of a single run are not kept in the config file.
+	for _, key := range []string{"config", "help", "yes", "dry-run"} {
+		if knownSetting(key) {
+			t.Errorf("flag %q is listed as a setting", key)
+		}
+	}
+}
+
+func TestConfigGetPrintsValueOnly(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+	env.writeFile("config.yaml", "server-name: go.example.com\n")
+
+	var err error
+	out := capture(t, &os.Stdout, func() {
+		err = env.execute("config", "get", "server-name")
+	})
+	if err != nil {
+		t.Fatalf("config get: %v", err)
+	}
+	if out != "go.example.com\n" {
+		t.Errorf("got %q, want the value only", out)
+	}
+}
+
+func TestEnvironmentKeys(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+	vars := map[string]string{
+		"GRPC_CLIENT_TIMEOUT":      "3s",
+		"GRPC_CLIENT_WAIT_TIMEOUT": "1m0s",
+		"GRPC_CLIENT_TOKEN":        "true",
+		// variables of other programs are not taken.
+		"TIMEOUT":      "7s",
+		"WAIT_TIMEOUT": "7s",
+		"TOKEN":        "false",
+	}
+	for name, value := range vars {
+		os.Setenv(name, value)
+		defer os.Unsetenv(name)
+	}
+
+	for key, want := range map[string]string{"timeout": "3s", "wait-timeout": "1m0s", "token": "true"} {
+		var err error
+		out := capture(t, &os.Stdout, func() {
+			err = env.execute("config", "get", key)
+		})
+		if err != nil {
+			t.Fatalf("config get %s: %v", key, err)
+		}
+		if out != want+"\n" {
+			t.Errorf("%s: got %q, want %q", key, out, want)
+		}
+		if source := valueSource(key); source != sourceEnv {
+			t.Errorf("%s: the source is %q, want %q", key, source, sourceEnv)
+		}
+	}
+	if got := timeoutsFromViper(); got.Call != 3*time.Second || got.Wait != time.Minute {
+		t.Errorf("timeouts: got %+v, want 3s and 1m0s", got)
+	}
+}
+
+// showLine returns the line of the setting printed by config show, with spaces squeezed.
+func showLine(out, key string) string {
+	for _, line := range strings.Split(out, "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 && fields[0] == key {
+			return strings.Join(fields, " ")
+		}
+	}
+	return ""
+}
+
+func TestConfigShow(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+	defer resetSettings()
+	env.writeFile("config.yaml", "port: 7000\npassword: secret\n")
+	// empty variables are not taken.
+	os.Setenv("GRPC_CLIENT_SERVER_NAME", "")
+	defer os.Unsetenv("GRPC_CLIENT_SERVER_NAME")
+	os.Setenv("GRPC_CLIENT_CERT", "env.crt")
+	defer os.Unsetenv("GRPC_CLIENT_CERT")
+
+	out, err := env.config("config", "show", "-l", "me")
+	if err != nil {
+		t.Fatalf("config show: %v", err)
+	}
+	if !strings.HasPrefix(out, "Config file: "+filepath.Join(env.dir, "config.yaml")+"\n") {
+		t.Errorf("the config file is not shown: %q", out)
+	}
+	for key, want := range map[string]string{
+		"login":       "login me flag",
+		"cert":        "cert env.crt env",
+		"port":        "port 7000 config",
+		"address":     "address localhost default",
+		"server-name": "server-name default",
+		"password":    "password ******** config",
+	} {
+		if got := showLine(out, key); got != want {
+			t.Errorf("%s: got %q, want %q", key, got, want)
+		}
+	}
+
+	out, err = env.config("config", "show", "--show-secrets")
+	if err != nil {
+		t.Fatalf("config show --show-secrets: %v", err)
+	}
+	if got, want := showLine(out, "password"), "password secret config"; got != want {
+		t.Errorf("password: got %q, want %q", got, want)
+	}
+}
+
+func TestConfigSet(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+	defer resetSettings()
+	env.writeFile("config.yaml", "login: me\n")
+
+	for _, args := range [][]string{
+		{"config", "set", "port", "7000"},
+		{"config", "set", "ca", "a.crt,b.crt"},
+		{"config", "set", "wait-timeout", "1m"},
+		{"config", "set", "store-passphrase", "passphrase"},
+	} {
+		if _, err := env.config(args...); err != nil {
+			t.Fatalf("%s: %v", strings.Join(args, " "), err)
+		}
+	}
+	v := readConfig(t, env)
+	if port := v.Get("port"); port != 7000 {
+		t.Errorf("port: got %#v, want 7000", port)
+	}
+	if ca := v.GetStringSlice("ca"); !reflect.DeepEqual(ca, []string{"a.crt", "b.crt"}) {
+		t.Errorf("ca: got %v", ca)
+	}
+	if timeout := v.Get("wait-timeout"); timeout != "1m" {
+		t.Errorf("wait-timeout: got %#v, want \"1m\"", timeout)
+	}
+	// other settings are kept.
+	if login := v.GetString("login"); login != "me" {
+		t.Errorf("login: got %q, want me", login)
+	}
+	info, err := os.Stat(filepath.Join(env.dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("config file: got mode %v, want 0600", perm)
+	}
+
+	for _, args := range [][]string{
+		{"config", "set", "unknown", "1"},
+		{"config", "set", "port", "many"},
+		{"config", "set", "wait-timeout", "long"},
+	} {
+		if _, err := env.config(args...); exitCode(err) != exitUsage {
+			t.Errorf("%s: got %v, want the usage error", strings.Join(args, " "), err)
+		}
+	}
+
+	// the value overridden now is saved with the note.
+	out, err := env.config("config", "set", "login", "other", "-l", "flag")
+	if err != nil {
+		t.Fatalf("config set login: %v", err)
+	}
+	if !strings.Contains(out, "overridden now by the flag") {
+		t.Errorf("no note about the flag: %q", out)
+	}
+}
+
+func TestConfigInit(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+	defer resetSettings()
+	path := filepath.Join(env.dir, "config.yaml")
+	os.Remove(path)
+
+	if _, err := env.config("config", "init"); err != nil {
+		t.Fatalf("config init: %v", err)
+	}
+	v := readConfig(t, env)
+	if address, port := v.GetString("address"), v.GetInt("port"); address != "localhost" || port != 7777 {
+		t.Errorf("the template: got %s:%d, want localhost:7777", address, port)
+	}
+
+	env.writeFile("config.yaml", "login: me\n")
+	if _, err := env.config("config", "init"); exitCode(err) != exitUsage {
+		t.Errorf("config init of the existing file: got %v, want the usage error", err)
+	}
+	if data, _ := ioutil.ReadFile(path); string(data) != "login: me\n" {
+		t.Errorf("the existing file is changed: %q", data)
+	}
+	if _, err := env.config("config", "init", "--force"); err != nil {
+		t.Fatalf("config init --force: %v", err)
+	}
+	if data, _ := ioutil.ReadFile(path); string(data) != configTemplate {
+		t.Errorf("the file is not overwritten: %q", data)
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
+)
+
+// configPath returns the path to the config file in use,
+// or to the default one, if there is no such yet.
+func configPath() (string, error) {
+	if cfgFile != "" {
+		return cfgFile, nil
+	}
+	if used := viper.ConfigFileUsed(); used != "" {
+		return used, nil
+	}
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".grpc_client.yaml"), nil
+}
+
+// loadConfigFile reads the config file alone, without flags and environment,
+// so it may be changed and written back.
+// The file is empty, if it does not exist yet.
+func loadConfigFile() (*viper.Viper, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	v := viper.New()
+	v.SetConfigFile(path)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return v, nil
+	}
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("can't read config file: %w", err)
+	}
+	return v, nil
+}
+
+// saveConfigFile writes the config file readable by the user only,
+// as it may contain the password.
+func saveConfigFile(v *viper.Viper) error {
+	path := v.ConfigFileUsed()
+	if err := v.WriteConfigAs(path); err != nil {
+		return fmt.Errorf("can't write config file: %w", err)
+	}
+	return os.Chmod(path, 0600)
+}
@@ -0,0 +1,62 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/yagoggame/grpc_client/client"
+	"github.com/yagoggame/grpc_client/terminal"
+)
+
+var (
+	// assumeYes confirms all actions without asking.
+	assumeYes bool
+	// dryRun makes commands report actions instead of performing them.
+	dryRun bool
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "confirm actions without asking, required when there is no answer in the input")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "report actions on the service instead of performing them")
+}
+
+// confirm asks the user to confirm the action, unless --yes is given.
+func confirm(question string) error {
+	err := terminal.NewConfirmer(os.Stdin, messages(), assumeYes).Confirm(question)
+	switch {
+	case errors.Is(err, terminal.ErrNotConfirmed):
+		return errCanceled
+	case errors.Is(err, terminal.ErrNoAnswer):
+		return fmt.Errorf("%w: %s, use --yes to confirm", errCanceled, err)
+	}
+	return err
+}
+
+// reportDryRun prints the action, if --dry-run is given, and reports it.
+func reportDryRun(format string, args ...interface{}) bool {
+	switch {
+	case dryRun && jsonOutput():
+		emit(client.Event{Event: client.EventDryRun, Message: fmt.Sprintf(format, args...)})
+	case dryRun:
+		fmt.Printf("Dry run: "+format+"\n", args...)
+	}
+	return dryRun
+}
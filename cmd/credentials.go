@@ -0,0 +1,86 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
+	"github.com/yagoggame/grpc_client/client"
+	"github.com/yagoggame/grpc_client/credstore"
+)
+
+// Kinds of the store of credentials.
+const (
+	storeAuto    = "auto"
+	storeKeyring = "keyring"
+	storeFile    = "file"
+)
+
+// defaultStoreFile returns the default path to the encrypted file with credentials.
+func defaultStoreFile() string {
+	home, err := homedir.Dir()
+	if err != nil {
+		return ".grpc_client.credentials"
+	}
+	return filepath.Join(home, ".grpc_client.credentials")
+}
+
+// openStore returns the store of credentials selected with the "store" key.
+func openStore() (credstore.Store, error) {
+	file := &credstore.File{
+		Path:          viper.GetString("store-file"),
+		Passphrase:    storePassphrase,
+		NewPassphrase: newStorePassphrase,
+	}
+	switch kind := viper.GetString("store"); kind {
+	case storeAuto:
+		return credstore.Open(file), nil
+	case storeKeyring:
+		return credstore.Keyring{}, nil
+	case storeFile:
+		return file, nil
+	default:
+		return nil, fmt.Errorf("unknown store of credentials %q, should be one of %s, %s, %s", kind, storeAuto, storeKeyring, storeFile)
+	}
+}
+
+// storeKey returns the key of credentials of the user on the server.
+func storeKey(initData *client.IniDataContainer) string {
+	return credstore.Key(initData.Login, fmt.Sprintf("%s:%d", initData.IP, initData.Port))
+}
+
+// storePassphrase returns the passphrase of the encrypted file with credentials
+// from the "store-passphrase" key or asks the user.
+func storePassphrase() (string, error) {
+	if passphrase := viper.GetString("store-passphrase"); passphrase != "" {
+		return passphrase, nil
+	}
+	return askPassword("Passphrase of the file with credentials: ")
+}
+
+// newStorePassphrase returns the passphrase of the new encrypted file with credentials
+// from the "store-passphrase" key or asks the user twice.
+func newStorePassphrase() (string, error) {
+	if passphrase := viper.GetString("store-passphrase"); passphrase != "" {
+		return passphrase, nil
+	}
+	return askNewSecret("passphrase of the file with credentials", errPassphraseMismatch)
+}
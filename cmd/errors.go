@@ -0,0 +1,78 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/yagoggame/grpc_client/client"
+)
+
+// Exit codes of the commands.
+const (
+	exitOK = iota
+	exitFailure
+	exitUsage
+	exitCanceled
+	exitUnavailable
+	exitUnauthenticated
+	exitGameAborted
+	exitDeadlineExceeded
+)
+
+// errCanceled is returned when the user does not confirm an action.
+var errCanceled = errors.New("canceled")
+
+// usageError is an error of command line usage.
+type usageError struct {
+	msg   string
+	usage string
+}
+
+func (e *usageError) Error() string {
+	return fmt.Sprintf("%s\n%s", e.msg, e.usage)
+}
+
+// newUsageError returns the error with usage of the command.
+func newUsageError(command *cobra.Command, msg string) error {
+	return &usageError{msg: msg, usage: command.UsageString()}
+}
+
+// exitCode returns the exit code of the process finished with err.
+func exitCode(err error) int {
+	var usageErr *usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &usageErr):
+		return exitUsage
+	case errors.Is(err, errCanceled):
+		return exitCanceled
+	case errors.Is(err, client.ErrUnavailable):
+		return exitUnavailable
+	case errors.Is(err, client.ErrUnauthenticated):
+		return exitUnauthenticated
+	case errors.Is(err, client.ErrGameAborted):
+		return exitGameAborted
+	case errors.Is(err, client.ErrDeadlineExceeded):
+		return exitDeadlineExceeded
+	}
+	return exitFailure
+}
@@ -0,0 +1,62 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/yagoggame/grpc_client/client"
+)
+
+func TestExitCode(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want int
+	}{
+		{nil, exitOK},
+		{errors.New("unknown"), exitFailure},
+		{&usageError{msg: "wrong flag"}, exitUsage},
+		{fmt.Errorf("%w: not confirmed", errCanceled), exitCanceled},
+		{&client.StatusError{Op: "EnterTheLobby", Err: client.ErrUnavailable}, exitUnavailable},
+		{&client.StatusError{Op: "EnterTheLobby", Err: client.ErrUnauthenticated}, exitUnauthenticated},
+		{fmt.Errorf("can't wait a turn: %w", &client.StatusError{Op: "WaitTheTurn", Err: client.ErrGameAborted}), exitGameAborted},
+		{&client.StatusError{Op: "WaitTheTurn", Err: client.ErrDeadlineExceeded}, exitDeadlineExceeded},
+	} {
+		if got := exitCode(tc.err); got != tc.want {
+			t.Errorf("exitCode(%v): got %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestReportErrorToStderr(t *testing.T) {
+	var stderr string
+	stdout := capture(t, &os.Stdout, func() {
+		stderr = capture(t, &os.Stderr, func() {
+			reportError(errors.New("connection refused"), "Check the address.")
+		})
+	})
+	if stdout != "" {
+		t.Errorf("the error is written to stdout: %q", stdout)
+	}
+	if want := "connection refused\nCheck the address.\n"; stderr != want {
+		t.Errorf("stderr: got %q, want %q", stderr, want)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/yagoggame/grpc_client/client"
+	"github.com/yagoggame/grpc_client/credstore"
+)
+
+// loginCmd represents the login command
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "save user's credentials",
+	Long: `save user's credentials for the service in the keyring of the OS
+or in the encrypted file, when the keyring is not available.
+The password is taken from there, when it is not given.
+The passphrase of the file is asked, twice when the file is created,
+or taken from the store-passphrase key of the config
+or GRPC_CLIENT_STORE_PASSPHRASE environment variable.`,
+	RunE: loginCmdFnc,
+}
+
+func init() {
+	rootCmd.AddCommand(loginCmd)
+	loginCmd.Flags().Bool("forget", false, "remove saved credentials instead")
+}
+
+func loginCmdFnc(cmd *cobra.Command, args []string) error {
+	initData := new(client.IniDataContainer)
+	readConnectionData(initData)
+	if initData.Login == "" {
+		return newUsageError(cmd, "login should be specified.")
+	}
+
+	store, err := openStore()
+	if err != nil {
+		return newUsageError(cmd, err.Error())
+	}
+	key := storeKey(initData)
+
+	if forget, _ := cmd.Flags().GetBool("forget"); forget {
+		if err := store.Delete(key); err != nil && !errors.Is(err, credstore.ErrNotFound) {
+			return fmt.Errorf("can't remove credentials: %w", err)
+		}
+		fmt.Printf("Credentials of %q are removed from %s\n", key, store)
+		return nil
+	}
+
+	password, err := givenPassword()
+	if err != nil {
+		return err
+	}
+	if password == "" {
+		if password, err = askPassword(fmt.Sprintf("Password of %q: ", key)); err != nil && err != io.EOF {
+			return fmt.Errorf("can't read the password: %w", err)
+		}
+	}
+	if password == "" {
+		return newUsageError(cmd, "password should be specified.")
+	}
+
+	if err := store.Set(key, password); err != nil {
+		return fmt.Errorf("can't save credentials: %w", err)
+	}
+	fmt.Printf("Credentials of %q are saved in %s\n", key, store)
+	return nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/yagoggame/grpc_client/client"
+)
+
+// Formats of the output.
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// events writes events to the standard output, when they are requested.
+var events = client.NewEventWriter(os.Stdout)
+
+func init() {
+	rootCmd.PersistentFlags().StringP("output", "o", outputText, "format of the output: text for humans or json for scripts, one event per line")
+	viper.BindPFlag("output", rootCmd.Flag("output"))
+	rootCmd.PersistentPreRunE = checkOutput
+}
+
+// checkOutput rejects unknown formats of the output.
+func checkOutput(cmd *cobra.Command, args []string) error {
+	switch viper.GetString("output") {
+	case outputText, outputJSON:
+		return nil
+	}
+	return newUsageError(cmd, fmt.Sprintf("unknown output format %q, should be text or json.", viper.GetString("output")))
+}
+
+// jsonOutput reports whether events are written instead of the text.
+func jsonOutput() bool {
+	return viper.GetString("output") == outputJSON
+}
+
+// messages returns the output of messages for the user.
+// They are written to the standard error with JSON output,
+// so the standard output has events only.
+func messages() io.Writer {
+	if jsonOutput() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// emit writes the event, if events are requested.
+func emit(ev client.Event) {
+	if jsonOutput() {
+		events.Emit(ev)
+	}
+}
+
+// reportConnection reports the connection to the server.
+func reportConnection(initData *client.IniDataContainer) {
+	emit(client.Event{
+		Event:   client.EventConnection,
+		Login:   initData.Login,
+		Address: fmt.Sprintf("%s:%d", initData.IP, initData.Port),
+	})
+}
+
+// reportDone reports the successful call of the account function op.
+func reportDone(op, login string) {
+	if jsonOutput() {
+		emit(client.Event{Event: client.EventDone, Op: op, Login: login})
+		return
+	}
+	log.Print("Done")
+}
+
+// reportError reports the error the command is finished with.
+// hint, if not empty, explains how to deal with it.
+func reportError(err error, hint string) {
+	if jsonOutput() {
+		events.Emit(client.Event{Event: client.EventError, Error: client.NewErrorInfo(err), Message: hint})
+		return
+	}
+	fmt.Fprintln(os.Stderr, err)
+	if hint != "" {
+		fmt.Fprintln(os.Stderr, hint)
+	}
+}
@@ -0,0 +1,235 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+	"github.com/yagoggame/grpc_client/client"
+)
+
+// eventBuffer keeps events written by commands and the game running in background.
+type eventBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *eventBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// events decodes events written so far.
+func (b *eventBuffer) events(t *testing.T) []client.Event {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var got []client.Event
+	dec := json.NewDecoder(bytes.NewReader(b.buf.Bytes()))
+	for dec.More() {
+		var ev client.Event
+		if err := dec.Decode(&ev); err != nil {
+			t.Fatalf("wrong event: %v", err)
+		}
+		got = append(got, ev)
+	}
+	return got
+}
+
+// waitFor waits for n events matching the condition.
+func (b *eventBuffer) waitFor(t *testing.T, n int, match func(client.Event) bool) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		found := 0
+		for _, ev := range b.events(t) {
+			if match(ev) {
+				found++
+			}
+		}
+		if found >= n {
+			return
+		}
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-timeout:
+			t.Fatalf("%d events are not written, got %+v", n, b.events(t))
+		}
+	}
+}
+
+// recordEvents makes commands write events into the buffer.
+// The standard output is restored with the returned function.
+func recordEvents() (*eventBuffer, func()) {
+	buf := new(eventBuffer)
+	events = client.NewEventWriter(buf)
+	return buf, func() {
+		events = client.NewEventWriter(os.Stdout)
+		rootCmd.PersistentFlags().Set("output", outputText)
+	}
+}
+
+// kinds returns types of events.
+func kinds(events []client.Event) string {
+	names := make([]string, len(events))
+	for i, ev := range events {
+		names[i] = ev.Event
+		if ev.Mode != "" {
+			names[i] += ":" + ev.Mode
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+func TestGameEvents(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+	buf, restore := recordEvents()
+	defer restore()
+	env.server.AddUser("alice", "1")
+	env.server.AddUser("bob", "2")
+
+	in, input, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	defer input.Close()
+	saved := os.Stdin
+	os.Stdin = in
+	defer func() { os.Stdin = saved }()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- env.execute("-l", "alice", "-p", "1", "--output", "json", "--reconnect", "0")
+	}()
+	input.WriteString("j\n")
+
+	// bob joins the game of alice, the one who joins first plays black.
+	conn, err := env.server.Connect(env.server.IniData("bob", "2"))
+	if err != nil {
+		t.Fatalf("can't connect: %v", err)
+	}
+	defer conn.Close()
+	ctx := context.Background()
+	bob, err := client.NewSession(ctx, api.NewGoGameClient(conn))
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	if _, err := bob.Join(ctx); err != nil {
+		t.Fatalf("Join: %v", err)
+	}
+	played := make(chan error, 1)
+	go func() {
+		for _, p := range []board.Point{{X: 4, Y: 4}, {X: 5, Y: 5}} {
+			if _, err := bob.WaitTurn(ctx); err != nil {
+				played <- err
+				return
+			}
+			if _, err := bob.Move(ctx, p.X, p.Y); err != nil {
+				played <- err
+				return
+			}
+		}
+		played <- nil
+	}()
+	turn := func(ev client.Event) bool { return ev.Event == client.EventPrompt && ev.Mode == "turn" }
+	buf.waitFor(t, 1, turn)
+	input.WriteString("C3\n")
+	buf.waitFor(t, 2, turn)
+	input.WriteString("e\nq\n")
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("game: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the game is not finished")
+	}
+	if err := <-played; err != nil && !errors.Is(err, client.ErrGameAborted) {
+		t.Errorf("bob: %v", err)
+	}
+
+	// the colours depend on the order of joining, but the events don't.
+	got := buf.events(t)
+	want := "connection lobby prompt:lobby prompt:wait-join joined prompt:wait-turn " +
+		"state prompt:turn state prompt:wait-turn state prompt:turn left prompt:lobby prompt:lobby"
+	if kinds(got) != want {
+		t.Fatalf("events:\n%s\nwant:\n%s", kinds(got), want)
+	}
+	if got[0].Login != "alice" || got[0].Address != "localhost:7777" {
+		t.Errorf("connection event: %+v", got[0])
+	}
+	own := got[8].State
+	if own == nil || own.Board[2][2] == '.' {
+		t.Errorf("the move is not in the state: %+v", own)
+	}
+}
+
+func TestAccountEvents(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+	buf, restore := recordEvents()
+	defer restore()
+
+	if err := env.execute("register", "-l", "alice", "-p", "secret", "--output", "json"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	newPassword := env.writeFile("new-password", "newsecret\n")
+	if err := env.execute("change", "bob", "-l", "alice", "-p", "secret", "--new-password-file", newPassword, "--output", "json"); err != nil {
+		t.Fatalf("change: %v", err)
+	}
+	if err := env.execute("remove", "-l", "bob", "-p", "newsecret", "--output", "json"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	err := env.execute("remove", "-l", "bob", "-p", "newsecret", "--output", "json")
+	if err == nil {
+		t.Fatal("remove of the removed user: error expected")
+	}
+	reportError(err, "")
+
+	got := buf.events(t)
+	want := "connection done connection done connection done connection error"
+	if kinds(got) != want {
+		t.Fatalf("events: %s, want %s", kinds(got), want)
+	}
+	for i, op := range []struct{ name, login string }{
+		{"RegisterUser", "alice"},
+		{"ChangeUserRequisits", "alice"},
+		{"RemoveUser", "bob"},
+	} {
+		if ev := got[2*i+1]; ev.Op != op.name || ev.Login != op.login {
+			t.Errorf("event of %s: got %+v", op.name, ev)
+		}
+	}
+	if ev := got[7]; ev.Error == nil || ev.Error.Code != "Unauthenticated" {
+		t.Errorf("error event: got %+v", ev)
+	}
+}
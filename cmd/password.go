@@ -0,0 +1,97 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/yagoggame/grpc_client/terminal"
+)
+
+var (
+	// errPasswordMismatch is returned when the new password is not repeated correctly.
+	errPasswordMismatch = errors.New("passwords do not match")
+	// errPassphraseMismatch is returned when the new passphrase is not repeated correctly.
+	errPassphraseMismatch = errors.New("passphrases do not match")
+)
+
+// givenPassword returns the password given with the "password" key
+// or in the file given with the "password-file" key,
+// or empty string if there is no such.
+func givenPassword() (string, error) {
+	if password := viper.GetString("password"); password != "" {
+		return password, nil
+	}
+	if file := viper.GetString("password-file"); file != "" {
+		return passwordFromFile(file)
+	}
+	return "", nil
+}
+
+// passwordFromFile reads the password from the first line of the file.
+func passwordFromFile(file string) (string, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("can't read the password: %w", err)
+	}
+	password := strings.SplitN(string(data), "\n", 2)[0]
+	return strings.TrimSuffix(password, "\r"), nil
+}
+
+// askPassword asks the password on the terminal without echo,
+// or reads it from the standard input, if it is not a terminal.
+func askPassword(prompt string) (string, error) {
+	return terminal.ReadPassword(prompt, os.Stdin, os.Stderr)
+}
+
+// stdinIsTerminal reports whether the standard input is a terminal.
+// Tests replace it to check questions asked on the terminal.
+var stdinIsTerminal = func() bool {
+	return terminal.IsTerminal(os.Stdin)
+}
+
+// askNewPassword asks the new password twice on the terminal,
+// or reads it once from the standard input, if it is not a terminal.
+func askNewPassword() (string, error) {
+	return askNewSecret("password", errPasswordMismatch)
+}
+
+// askNewSecret asks the new secret named what like askNewPassword.
+// mismatch is returned, if the secret is not repeated correctly.
+func askNewSecret(what string, mismatch error) (string, error) {
+	secret, err := askPassword(fmt.Sprintf("New %s: ", what))
+	if err != nil {
+		return "", err
+	}
+	if !stdinIsTerminal() {
+		return secret, nil
+	}
+	repeated, err := askPassword(fmt.Sprintf("Repeat the new %s: ", what))
+	if err != nil {
+		return "", err
+	}
+	if repeated != secret {
+		return "", mismatch
+	}
+	return secret, nil
+}
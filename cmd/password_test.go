@@ -0,0 +1,55 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"io"
+	"testing"
+)
+
+func TestAskNewPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		terminal bool
+		input    string
+		want     string
+		err      error
+	}{
+		{"non-terminal", false, "secret\nother\n", "secret", nil},
+		{"non-terminal EOF", false, "", "", io.EOF},
+		{"confirmed", true, "secret\nsecret\n", "secret", nil},
+		{"mismatch", true, "secret\nother\n", "", errPasswordMismatch},
+		{"EOF of confirmation", true, "secret\n", "", io.EOF},
+	}
+	defer func(saved func() bool) { stdinIsTerminal = saved }(stdinIsTerminal)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stdinIsTerminal = func() bool { return test.terminal }
+			var (
+				password string
+				err      error
+			)
+			withStdin(t, test.input, func() {
+				password, err = askNewPassword()
+			})
+			if password != test.want || err != test.err {
+				t.Errorf("got %q, %v, want %q, %v", password, err, test.want, test.err)
+			}
+		})
+	}
+}
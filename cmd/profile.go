@@ -0,0 +1,194 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// profileKeys are the keys kept in profiles.
+var profileKeys = []string{
+	"address", "port", "cert", "ca", "system-roots", "server-name",
+	"client-cert", "client-key", "insecure", "token", "login",
+}
+
+// profileCmd represents the profile command
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "manage profiles of servers",
+	Long: `manage named profiles of servers in the config file.
+A profile keeps the address, port, certificates and login to use with the server.
+The profile is selected with --profile or the profile key of the config file,
+its values are overridden with flags and environment variables.`,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list profiles",
+	Args:  cobra.NoArgs,
+	RunE:  profileListCmdFnc,
+}
+
+var profileAddCmd = &cobra.Command{
+	Use:   "add name",
+	Short: "add or replace the profile",
+	Long: `add or replace the profile with the settings given with flags, e.g.
+  grpc_client profile add staging -A staging.example.com -P 7777 -C staging.crt -l me`,
+	Args: cobra.ExactArgs(1),
+	RunE: profileAddCmdFnc,
+}
+
+var profileRemoveCmd = &cobra.Command{
+	Use:   "remove name",
+	Short: "remove the profile",
+	Args:  cobra.ExactArgs(1),
+	RunE:  profileRemoveCmdFnc,
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use name",
+	Short: "make the profile current",
+	Args:  cobra.ExactArgs(1),
+	RunE:  profileUseCmdFnc,
+}
+
+func init() {
+	rootCmd.AddCommand(profileCmd)
+	profileCmd.AddCommand(profileListCmd, profileAddCmd, profileRemoveCmd, profileUseCmd)
+}
+
+// profileKey returns the key of the profile with the name in the config.
+// Viper keys are case insensitive, so are names of profiles.
+func profileKey(name string) string {
+	return "profiles." + strings.ToLower(name)
+}
+
+// applyProfile makes values of the selected profile, if any, effective.
+// Values given with flags or environment variables are kept.
+func applyProfile() error {
+	name := viper.GetString("profile")
+	if name == "" {
+		return nil
+	}
+	profile := viper.GetStringMap(profileKey(name))
+	if len(profile) == 0 {
+		return fmt.Errorf("unknown profile %q", name)
+	}
+	for key, value := range profile {
+		if source := valueSource(key); source != sourceFlag && source != sourceEnv {
+			viper.Set(key, value)
+			profileApplied[key] = true
+		}
+	}
+	return nil
+}
+
+// profileNames returns sorted names of profiles in the config.
+func profileNames(v *viper.Viper) []string {
+	var names []string
+	for name := range v.GetStringMap("profiles") {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func profileListCmdFnc(cmd *cobra.Command, args []string) error {
+	v, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+	current := strings.ToLower(viper.GetString("profile"))
+	for _, name := range profileNames(v) {
+		mark := " "
+		if name == current {
+			mark = "*"
+		}
+		profile := v.Sub(profileKey(name))
+		fmt.Printf("%s %s\t%s@%s:%d\n", mark, name,
+			profile.GetString("login"), profile.GetString("address"), profile.GetInt("port"))
+	}
+	return nil
+}
+
+func profileAddCmdFnc(cmd *cobra.Command, args []string) error {
+	v, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+	// defaults are not kept, so they may be changed with the config file.
+	profile := make(map[string]interface{})
+	for _, key := range profileKeys {
+		if cmd.Flags().Changed(key) {
+			profile[key] = viper.Get(key)
+		}
+	}
+	if len(profile) == 0 {
+		return newUsageError(cmd, "settings of the profile should be given with flags.")
+	}
+	v.Set(profileKey(args[0]), profile)
+	if err := saveConfigFile(v); err != nil {
+		return err
+	}
+	fmt.Printf("Profile %q is saved in %s\n", args[0], v.ConfigFileUsed())
+	return nil
+}
+
+func profileRemoveCmdFnc(cmd *cobra.Command, args []string) error {
+	v, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+	profiles := v.GetStringMap("profiles")
+	name := strings.ToLower(args[0])
+	if _, ok := profiles[name]; !ok {
+		return newUsageError(cmd, fmt.Sprintf("unknown profile %q.", args[0]))
+	}
+	delete(profiles, name)
+	v.Set("profiles", profiles)
+	if strings.ToLower(v.GetString("profile")) == name {
+		v.Set("profile", "")
+	}
+	if err := saveConfigFile(v); err != nil {
+		return err
+	}
+	fmt.Printf("Profile %q is removed from %s\n", args[0], v.ConfigFileUsed())
+	return nil
+}
+
+func profileUseCmdFnc(cmd *cobra.Command, args []string) error {
+	v, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+	name := strings.ToLower(args[0])
+	if len(v.GetStringMap(profileKey(name))) == 0 {
+		return newUsageError(cmd, fmt.Sprintf("unknown profile %q.", args[0]))
+	}
+	v.Set("profile", name)
+	if err := saveConfigFile(v); err != nil {
+		return err
+	}
+	fmt.Printf("Profile %q is current now\n", args[0])
+	return nil
+}
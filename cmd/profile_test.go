@@ -0,0 +1,155 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// readConfig reads the config file of the environment.
+func readConfig(t *testing.T, e *testEnv) *viper.Viper {
+	t.Helper()
+	v := viper.New()
+	v.SetConfigFile(filepath.Join(e.dir, "config.yaml"))
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatalf("can't read config file: %v", err)
+	}
+	return v
+}
+
+func TestProfileAddSavesGivenFlags(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+	defer resetSettings()
+	env.writeFile("config.yaml", "")
+
+	if _, err := env.config("profile", "add", "staging", "-A", "staging.example.com", "-l", "me", "--ca", "a.crt,b.crt"); err != nil {
+		t.Fatalf("profile add: %v", err)
+	}
+	got := readConfig(t, env).GetStringMap(profileKey("staging"))
+	want := map[string]interface{}{
+		"address": "staging.example.com",
+		"login":   "me",
+		"ca":      []interface{}{"a.crt", "b.crt"},
+	}
+	// defaults, e.g. the port, are not saved.
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := env.config("profile", "add", "empty"); exitCode(err) != exitUsage {
+		t.Errorf("profile add without flags: got %v, want the usage error", err)
+	}
+}
+
+func TestProfileCommands(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+	defer resetSettings()
+	env.writeFile("config.yaml", "")
+
+	for _, args := range [][]string{
+		{"profile", "add", "staging", "-A", "staging.example.com", "-l", "me"},
+		{"profile", "add", "local", "-A", "localhost", "-P", "7778", "-l", "dev"},
+		{"profile", "use", "staging"},
+	} {
+		if _, err := env.config(args...); err != nil {
+			t.Fatalf("%s: %v", strings.Join(args, " "), err)
+		}
+	}
+	out, err := env.config("profile", "list")
+	if err != nil {
+		t.Fatalf("profile list: %v", err)
+	}
+	want := "  local\tdev@localhost:7778\n* staging\tme@staging.example.com:0\n"
+	if out != want {
+		t.Errorf("profile list: got %q, want %q", out, want)
+	}
+
+	if _, err := env.config("profile", "use", "unknown"); exitCode(err) != exitUsage {
+		t.Errorf("profile use of unknown profile: got %v, want the usage error", err)
+	}
+	// the current profile is not current anymore.
+	if _, err := env.config("profile", "remove", "staging"); err != nil {
+		t.Fatalf("profile remove: %v", err)
+	}
+	v := readConfig(t, env)
+	if names := profileNames(v); !reflect.DeepEqual(names, []string{"local"}) {
+		t.Errorf("profiles after removal: got %v, want [local]", names)
+	}
+	if current := v.GetString("profile"); current != "" {
+		t.Errorf("the removed profile %q is current", current)
+	}
+	if _, err := env.config("profile", "remove", "staging"); exitCode(err) != exitUsage {
+		t.Errorf("the second profile remove: got %v, want the usage error", err)
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.close()
+	defer resetSettings()
+	env.writeFile("config.yaml", `profile: staging
+address: config.example.com
+profiles:
+  staging:
+    address: staging.example.com
+    port: 7778
+    login: me
+`)
+	os.Setenv("GRPC_CLIENT_LOGIN", "env")
+	defer os.Unsetenv("GRPC_CLIENT_LOGIN")
+
+	tests := []struct {
+		key    string
+		args   []string
+		want   string
+		source string
+	}{
+		{"address", nil, "staging.example.com", "profile staging"},
+		{"port", nil, "7778", "profile staging"},
+		// flags and the environment override the profile.
+		{"address", []string{"-A", "flag.example.com"}, "flag.example.com", sourceFlag},
+		{"login", nil, "env", sourceEnv},
+	}
+	for _, test := range tests {
+		out, err := env.config(append([]string{"config", "get", test.key}, test.args...)...)
+		if err != nil {
+			t.Fatalf("config get %s: %v", test.key, err)
+		}
+		if out != test.want+"\n" {
+			t.Errorf("%s %v: got %q, want %q", test.key, test.args, out, test.want)
+		}
+		if source := valueSource(test.key); source != test.source {
+			t.Errorf("%s %v: the source is %q, want %q", test.key, test.args, source, test.source)
+		}
+	}
+
+	resetSettings()
+	viper.Set("profile", "unknown")
+	defer viper.Set("profile", nil)
+	if err := applyProfile(); err == nil || !strings.Contains(err.Error(), "unknown profile") {
+		t.Errorf("applyProfile of unknown profile: got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/yagoggame/grpc_client/record"
+)
+
+// gameRecorder returns the function saving records of games into dir,
+// both in SGF and in the log format of the client.
+// Results of saving are reported to out.
+func gameRecorder(dir, login string, out io.Writer) func(*record.Game) {
+	return func(game *record.Game) {
+		game.SetPlayers(login, "")
+		base := filepath.Join(dir, fmt.Sprintf("%s-%s", game.Started.Format("20060102-150405"), login))
+		for _, f := range []struct {
+			name  string
+			write func(io.Writer) error
+		}{
+			{base + ".sgf", game.WriteSGF},
+			{base + ".jsonl", game.WriteLog},
+		} {
+			if err := saveFile(f.name, f.write); err != nil {
+				fmt.Fprintf(out, "can't save the game record: %s\n", err)
+				return
+			}
+			fmt.Fprintf(out, "The game record is saved to %s\n", f.name)
+		}
+	}
+}
+
+// saveFile creates the file and writes it with write function.
+func saveFile(name string, write func(io.Writer) error) (err error) {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+	return write(f)
+}
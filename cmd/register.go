@@ -18,12 +18,7 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package cmd
 
 import (
-	"bufio"
-	"context"
 	"fmt"
-	"log"
-	"os"
-	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/yagoggame/api"
@@ -35,37 +30,40 @@ var registerCmd = &cobra.Command{
 	Use:   "register",
 	Short: "register this user on the service",
 	Long:  `register this user on the service's data base`,
-	Run:   registerCmdFnc,
+	RunE:  registerCmdFnc,
 }
 
 func init() {
 	rootCmd.AddCommand(registerCmd)
 }
 
-func registerCmdFnc(cmd *cobra.Command, args []string) {
+func registerCmdFnc(cmd *cobra.Command, args []string) error {
 	initData := new(client.IniDataContainer)
-	iniFromViper(initData, cmd)
+	if err := iniFromViper(initData, cmd); err != nil {
+		return err
+	}
 
-	fmt.Printf("do you realy want to register %q user on service?\ntype \"yes\" if you do.\n", initData.Login)
-	scanner := bufio.NewScanner(os.Stdin)
-	if !scanner.Scan() {
-		log.Fatal("Canceled")
+	if reportDryRun("would register %q user on %s:%d", initData.Login, initData.IP, initData.Port) {
+		return nil
 	}
-	if txt := scanner.Text(); strings.Compare(txt, "yes") != 0 {
-		log.Fatal("Canceled")
+	if err := confirm(fmt.Sprintf("do you realy want to register %q user on service?", initData.Login)); err != nil {
+		return err
 	}
 
-	conn, err := client.Connect(initData)
+	conn, err := connect(initData)
 	if err != nil {
-		log.Fatalf("connection: %s", err)
+		return fmt.Errorf("connection: %w", err)
 	}
 	defer conn.Close()
+	reportConnection(initData)
 
 	c := api.NewGoGameClient(conn)
 
-	_, err = c.RegisterUser(context.Background(), &api.EmptyMessage{})
-	if err != nil {
-		log.Fatalf("RegisterUser: %s", err)
+	ctx, cancel := callContext()
+	defer cancel()
+	if err := client.RegisterUser(ctx, c); err != nil {
+		return err
 	}
-	log.Print("Done")
+	reportDone("RegisterUser", initData.Login)
+	return nil
 }
@@ -18,12 +18,7 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package cmd
 
 import (
-	"bufio"
-	"context"
 	"fmt"
-	"log"
-	"os"
-	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/yagoggame/api"
@@ -35,37 +30,40 @@ var removeCmd = &cobra.Command{
 	Use:   "remove",
 	Short: "remove this user from the service",
 	Long:  `remove this user from the service's data base`,
-	Run:   removeCmdFnc,
+	RunE:  removeCmdFnc,
 }
 
 func init() {
 	rootCmd.AddCommand(removeCmd)
 }
 
-func removeCmdFnc(cmd *cobra.Command, args []string) {
+func removeCmdFnc(cmd *cobra.Command, args []string) error {
 	initData := new(client.IniDataContainer)
-	iniFromViper(initData, cmd)
+	if err := iniFromViper(initData, cmd); err != nil {
+		return err
+	}
 
-	fmt.Printf("do you realy want to remove %q user?\ntype \"yes\" if you do.\n", initData.Login)
-	scanner := bufio.NewScanner(os.Stdin)
-	if !scanner.Scan() {
-		log.Fatal("Canceled")
+	if reportDryRun("would remove %q user from %s:%d", initData.Login, initData.IP, initData.Port) {
+		return nil
 	}
-	if txt := scanner.Text(); strings.Compare(txt, "yes") != 0 {
-		log.Fatal("Canceled")
+	if err := confirm(fmt.Sprintf("do you realy want to remove %q user?", initData.Login)); err != nil {
+		return err
 	}
 
-	conn, err := client.Connect(initData)
+	conn, err := connect(initData)
 	if err != nil {
-		log.Fatalf("connection: %s", err)
+		return fmt.Errorf("connection: %w", err)
 	}
 	defer conn.Close()
+	reportConnection(initData)
 
 	c := api.NewGoGameClient(conn)
 
-	_, err = c.RemoveUser(context.Background(), &api.EmptyMessage{})
-	if err != nil {
-		log.Fatalf("RemoveUser: %s", err)
+	ctx, cancel := callContext()
+	defer cancel()
+	if err := client.RemoveUser(ctx, c); err != nil {
+		return err
 	}
-	log.Print("Done")
+	reportDone("RemoveUser", initData.Login)
+	return nil
 }
@@ -0,0 +1,55 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yagoggame/grpc_client/client"
+	"github.com/yagoggame/grpc_client/record"
+)
+
+// replayCmd represents the replay command
+var replayCmd = &cobra.Command{
+	Use:   "replay file",
+	Short: "replay a saved game",
+	Long: `replay a game saved in SGF or in the log format of the client,
+step by step, without connection to the service`,
+	RunE: replayCmdFnc,
+	Args: cobra.ExactArgs(1),
+}
+
+func init() {
+	rootCmd.AddCommand(replayCmd)
+}
+
+func replayCmdFnc(cmd *cobra.Command, args []string) error {
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	game, err := record.Read(f)
+	if err != nil {
+		return fmt.Errorf("can't read %s: %w", args[0], err)
+	}
+	return client.Replay(game, os.Stdin, os.Stdout)
+}
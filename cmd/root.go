@@ -18,18 +18,27 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/yagoggame/api"
 	"github.com/yagoggame/grpc_client/client"
+	"github.com/yagoggame/grpc_client/credstore"
+	"github.com/yagoggame/grpc_client/tui"
 
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
 )
 
+// envPrefix is the prefix of environment variables of settings.
+const envPrefix = "GRPC_CLIENT"
+
 var (
 	cfgFile string
 	// rootCmd represents the base command when called without any subcommands
@@ -38,20 +47,32 @@ var (
 		Short: "grpc_client is a grpc client of yagogame.",
 		Long: `grpc_client is a part of yagogame.
 	yagogame is yet another Go game on the Go, made just for fun.
-	grpc_client provides access to the go game grpc_server thru grpc with CLI interface`,
+	grpc_client provides access to the go game grpc_server thru grpc with CLI interface.
+	Settings may be given with environment variables prefixed with GRPC_CLIENT_,
+	e.g. GRPC_CLIENT_LOGIN for --login and GRPC_CLIENT_WAIT_TIMEOUT for --wait-timeout.`,
 
 		// Uncomment the following line if your bare application
 		// has an action associated with it:
-		Run: mainCmdFnc,
+		RunE: mainCmdFnc,
+		// errors are reported by Execute, usage is a part of usageError.
+		SilenceErrors: true,
+		SilenceUsage:  true,
 	}
+	// connect establishes connections of all commands.
+	// Tests replace it to reach an in-process server, e.g. clienttest.Server.Connect.
+	connect = client.Connect
 )
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		var hint string
+		if errors.Is(err, client.ErrDeadlineExceeded) {
+			hint = "The server did not respond in time, the limits may be changed with --timeout and --wait-timeout."
+		}
+		reportError(err, hint)
+		os.Exit(exitCode(err))
 	}
 }
 
@@ -59,17 +80,72 @@ func init() {
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file (default is $HOME/.grpc_client.yaml)")
+	rootCmd.PersistentFlags().String("profile", "", "profile of the server from the config file to use")
+	viper.BindPFlag("profile", rootCmd.Flag("profile"))
 
 	rootCmd.PersistentFlags().StringP("login", "l", "", "login to use")
 	viper.BindPFlag("login", rootCmd.Flag("login"))
 	rootCmd.PersistentFlags().StringP("password", "p", "", "password to use")
 	viper.BindPFlag("password", rootCmd.Flag("password"))
+	rootCmd.PersistentFlags().String("password-file", "", "file with the password to use, it is asked when neither is given")
+	viper.BindPFlag("password-file", rootCmd.Flag("password-file"))
 	rootCmd.PersistentFlags().StringP("address", "A", "localhost", "ip address of grpc_server")
 	viper.BindPFlag("address", rootCmd.Flag("address"))
 	rootCmd.PersistentFlags().IntP("port", "P", 7777, "port of grpc_server")
 	viper.BindPFlag("port", rootCmd.Flag("port"))
-	rootCmd.PersistentFlags().StringP("cert", "C", "", "file with TLS certificate")
+	rootCmd.PersistentFlags().StringP("cert", "C", "", "file with TLS certificate, the system pool of CA certificates is used if no certificates are given")
 	viper.BindPFlag("cert", rootCmd.Flag("cert"))
+	rootCmd.PersistentFlags().StringSlice("ca", nil, "additional files with CA certificates to verify grpc_server, may be repeated")
+	viper.BindPFlag("ca", rootCmd.Flag("ca"))
+	rootCmd.PersistentFlags().Bool("system-roots", false, "trust the system pool of CA certificates too")
+	viper.BindPFlag("system-roots", rootCmd.Flag("system-roots"))
+	rootCmd.PersistentFlags().String("server-name", "", "name of grpc_server to verify its certificate, if differs from the address")
+	viper.BindPFlag("server-name", rootCmd.Flag("server-name"))
+	rootCmd.PersistentFlags().String("client-cert", "", "file with the client TLS certificate for mutual authentication")
+	viper.BindPFlag("client-cert", rootCmd.Flag("client-cert"))
+	rootCmd.PersistentFlags().String("client-key", "", "file with the key of the client TLS certificate")
+	viper.BindPFlag("client-key", rootCmd.Flag("client-key"))
+	rootCmd.PersistentFlags().Bool("insecure", false, "connect without TLS, for local development only: the password is sent in clear")
+	viper.BindPFlag("insecure", rootCmd.Flag("insecure"))
+	rootCmd.PersistentFlags().Bool("token", false, "exchange login and password for a token once and send the token with the next calls, the server must support it")
+	viper.BindPFlag("token", rootCmd.Flag("token"))
+	rootCmd.PersistentFlags().String("token-cache", defaultTokenCache(), "directory to keep tokens between runs, empty to keep them in memory only")
+	viper.BindPFlag("token-cache", rootCmd.Flag("token-cache"))
+	rootCmd.PersistentFlags().String("store", storeAuto, "store of credentials saved with login: auto, keyring or file")
+	viper.BindPFlag("store", rootCmd.Flag("store"))
+	rootCmd.PersistentFlags().String("store-file", defaultStoreFile(), "encrypted file with credentials, used when the keyring is not available")
+	viper.BindPFlag("store-file", rootCmd.Flag("store-file"))
+	rootCmd.PersistentFlags().Duration("keepalive", client.DefaultKeepalive, "period of pings to check the connection, the server should permit it")
+	viper.BindPFlag("keepalive", rootCmd.Flag("keepalive"))
+	rootCmd.PersistentFlags().Duration("keepalive-timeout", client.DefaultKeepaliveTimeout, "time to wait for the ping ack before the connection is considered dead")
+	viper.BindPFlag("keepalive-timeout", rootCmd.Flag("keepalive-timeout"))
+	rootCmd.PersistentFlags().Duration("timeout", client.DefaultTimeouts.Call, "time limit of a call to grpc_server, 0 means no limit")
+	viper.BindPFlag("timeout", rootCmd.Flag("timeout"))
+	rootCmd.PersistentFlags().Duration("wait-timeout", client.DefaultTimeouts.Wait, "time limit of waiting for another player to join or to make a turn, 0 means no limit")
+	viper.BindPFlag("wait-timeout", rootCmd.Flag("wait-timeout"))
+
+	rootCmd.Flags().BoolP("tui", "t", false, "use full-screen interface with cursor-based move entry")
+	viper.BindPFlag("tui", rootCmd.Flag("tui"))
+	rootCmd.Flags().StringP("record-dir", "r", "", "directory to save records of games in SGF format")
+	viper.BindPFlag("record-dir", rootCmd.Flag("record-dir"))
+	rootCmd.Flags().Int("reconnect", client.DefaultBackoff.Attempts, "number of attempts to reconnect when the connection is lost, 0 disables reconnection")
+	viper.BindPFlag("reconnect", rootCmd.Flag("reconnect"))
+	rootCmd.Flags().Duration("reconnect-delay", client.DefaultBackoff.Initial, "delay before the first attempt to reconnect, doubled by every next attempt")
+	viper.BindPFlag("reconnect-delay", rootCmd.Flag("reconnect-delay"))
+	// the bot plays games with the same settings, the flags are shared to keep them bound.
+	for _, name := range []string{"record-dir", "reconnect", "reconnect-delay"} {
+		botCmd.Flags().AddFlag(rootCmd.Flags().Lookup(name))
+	}
+}
+
+// defaultTokenCache returns the directory to keep tokens in the user's cache,
+// or empty string if there is no such.
+func defaultTokenCache() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "grpc_client", "tokens")
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -81,7 +157,7 @@ func initConfig() {
 		// Find home directory.
 		home, err := homedir.Dir()
 		if err != nil {
-			fmt.Println(err)
+			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
 
@@ -90,40 +166,209 @@ func initConfig() {
 		viper.SetConfigName(".grpc_client")
 	}
 
+	// e.g. GRPC_CLIENT_WAIT_TIMEOUT for wait-timeout,
+	// the prefix keeps common names like TOKEN or TIMEOUT of other programs away.
+	viper.SetEnvPrefix(envPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 	viper.AutomaticEnv() // read in environment variables that match
 
 	// If a config file is found, read it in.
+	// Notes go to the standard error to keep the output of commands clean for scripts.
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
+	}
+
+	if err := applyProfile(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitUsage)
+	}
+	if profile := viper.GetString("profile"); profile != "" {
+		fmt.Fprintln(os.Stderr, "Using profile:", profile)
 	}
 }
 
-func iniFromViper(initData *client.IniDataContainer, command *cobra.Command) {
+// readConnectionData reads data to connect to the server, except the password.
+func readConnectionData(initData *client.IniDataContainer) {
 	initData.Port = viper.GetInt("port")
 	initData.IP = viper.GetString("address")
 	initData.CertFile = viper.GetString("cert")
+	initData.CAFiles = viper.GetStringSlice("ca")
+	initData.SystemRoots = viper.GetBool("system-roots")
+	initData.ServerName = viper.GetString("server-name")
+	initData.ClientCertFile = viper.GetString("client-cert")
+	initData.ClientKeyFile = viper.GetString("client-key")
+	initData.Insecure = viper.GetBool("insecure")
+	initData.Token = viper.GetBool("token")
+	initData.TokenCacheDir = viper.GetString("token-cache")
 	initData.Login = viper.GetString("login")
-	initData.Password = viper.GetString("password")
+	initData.Keepalive = viper.GetDuration("keepalive")
+	initData.KeepaliveTimeout = viper.GetDuration("keepalive-timeout")
+}
+
+// iniFromViper reads data to connect to the server.
+// The password is taken from the store of credentials, if it is not given,
+// or asked from the user as the last resort.
+func iniFromViper(initData *client.IniDataContainer, command *cobra.Command) error {
+	readConnectionData(initData)
+	password, err := givenPassword()
+	if err != nil {
+		return err
+	}
+	initData.Password = password
+	if len(initData.Login) > 0 && len(initData.Password) < 1 {
+		if initData.Password, err = storedPassword(initData); err != nil {
+			return err
+		}
+	}
+	if len(initData.Login) > 0 && len(initData.Password) < 1 {
+		prompt := fmt.Sprintf("Password of %q: ", initData.Login)
+		if initData.Password, err = askPassword(prompt); err != nil && err != io.EOF {
+			return fmt.Errorf("can't read the password: %w", err)
+		}
+	}
 	if len(initData.Login) < 1 || len(initData.Password) < 1 {
-		log.Fatalf("login and password should be specified.\n%s", command.UsageString())
+		return newUsageError(command, "login and password should be specified.")
 	}
+	return checkInsecure(initData, command)
 }
 
-func mainCmdFnc(cmd *cobra.Command, args []string) {
+// checkInsecure rejects certificates given for the insecure connection
+// and warns the user about it.
+func checkInsecure(initData *client.IniDataContainer, command *cobra.Command) error {
+	if !initData.Insecure {
+		return nil
+	}
+	if initData.CertFile != "" || len(initData.CAFiles) > 0 || initData.ClientCertFile != "" {
+		return newUsageError(command, "certificates can't be used with insecure connection.")
+	}
+	fmt.Fprint(os.Stderr, insecureWarning)
+	return nil
+}
+
+// storedPassword returns the password saved with the login command,
+// or empty string if there is no such.
+// The store is opened only when the password is not given otherwise.
+// If the store fails, e.g. the passphrase is wrong, it is reported
+// and empty string is returned to ask the password instead.
+func storedPassword(initData *client.IniDataContainer) (string, error) {
+	store, err := openStore()
+	if err != nil {
+		return "", err
+	}
+	password, err := store.Get(storeKey(initData))
+	if errors.Is(err, credstore.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Can't read credentials from %s: %s\n", store, err)
+		return "", nil
+	}
+	return password, nil
+}
+
+// insecureWarning is printed when the connection is not secured.
+const insecureWarning = `
+!!! WARNING: INSECURE CONNECTION !!!
+TLS is disabled: the login and password are sent in clear
+and may be seen by anyone on the network.
+Use --insecure only to connect to a local server for development.
+`
+
+// timeoutsFromViper returns the time limits of calls to grpc_server.
+func timeoutsFromViper() client.Timeouts {
+	return client.Timeouts{
+		Call: viper.GetDuration("timeout"),
+		Wait: viper.GetDuration("wait-timeout"),
+	}
+}
+
+// callContext returns the context of a single call to grpc_server.
+func callContext() (context.Context, context.CancelFunc) {
+	return client.WithTimeout(context.Background(), timeoutsFromViper().Call)
+}
+
+func mainCmdFnc(cmd *cobra.Command, args []string) error {
 	initData := new(client.IniDataContainer)
-	iniFromViper(initData, cmd)
+	if err := iniFromViper(initData, cmd); err != nil {
+		return err
+	}
 
-	fmt.Printf("Hello: %s\n", initData.Login)
+	useTUI := viper.GetBool("tui")
+	if jsonOutput() && useTUI {
+		return newUsageError(cmd, "full-screen interface can't be used with JSON output.")
+	}
+
+	fmt.Fprintf(messages(), "Hello: %s\n", initData.Login)
 
-	conn, err := client.Connect(initData)
+	c, opts, closeConn, err := gameConnection(initData, useTUI)
 	if err != nil {
-		log.Fatalf("connection: %s", err)
+		return err
 	}
-	defer conn.Close()
-
-	c := api.NewGoGameClient(conn)
+	defer closeConn()
 
 	quit := client.HandleSignals()
 
-	client.GameFlow(c, quit)
+	if useTUI {
+		return tui.GameFlow(c, quit, opts...)
+	}
+	return client.GameFlow(c, os.Stdin, messages(), quit, opts...)
+}
+
+// gameConnection connects to the server to play games.
+// The options make the session reconnect, record games and report events as requested,
+// reconnections are not reported with the text when the full-screen interface is used,
+// it shows them itself.
+// closeConn closes the connection, when games are over.
+func gameConnection(initData *client.IniDataContainer, useTUI bool) (c api.GoGameClient, opts []client.SessionOption, closeConn func(), err error) {
+	conn, err := connect(initData)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("connection: %w", err)
+	}
+	reportConnection(initData)
+	monitor := client.NewMonitor()
+	monitor.Watch(conn)
+	closeConn = func() {
+		monitor.Stop()
+		conn.Close()
+	}
+
+	opts = []client.SessionOption{
+		client.WithMonitor(monitor),
+		client.WithTimeouts(timeoutsFromViper()),
+	}
+	if attempts := viper.GetInt("reconnect"); attempts > 0 {
+		backoff := client.DefaultBackoff
+		backoff.Attempts = attempts
+		backoff.Initial = viper.GetDuration("reconnect-delay")
+		// the session reconnects one at a time, so conn is replaced safely.
+		dial := func(ctx context.Context) (api.GoGameClient, error) {
+			newConn, err := connect(initData)
+			if err != nil {
+				return nil, err
+			}
+			monitor.Watch(newConn)
+			conn.Close()
+			conn = newConn
+			return api.NewGoGameClient(conn), nil
+		}
+		var notify func(int, error)
+		switch {
+		case jsonOutput():
+			notify = func(attempt int, err error) {
+				emit(client.Event{Event: client.EventReconnecting, Attempt: attempt, Error: client.NewErrorInfo(err)})
+			}
+		case !useTUI:
+			notify = func(attempt int, err error) {
+				fmt.Fprintf(messages(), "Connection lost: %s\nReconnecting, attempt %d of %d...\n", err, attempt, attempts)
+			}
+		}
+		opts = append(opts, client.WithReconnect(dial, backoff, notify))
+	}
+	if dir := viper.GetString("record-dir"); dir != "" {
+		opts = append(opts, client.WithRecorder(gameRecorder(dir, initData.Login, messages())))
+	}
+	if jsonOutput() {
+		opts = append(opts, client.WithEvents(events))
+	}
+	return api.NewGoGameClient(conn), opts, closeConn, nil
 }
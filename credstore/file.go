@@ -0,0 +1,228 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package credstore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrPassphrase is returned when the file can't be decrypted with the passphrase.
+var ErrPassphrase = errors.New("wrong passphrase")
+
+// Parameters of the key derivation.
+const (
+	scryptN   = 1 << 15
+	scryptR   = 8
+	scryptP   = 1
+	keyLength = 32
+	saltSize  = 16
+)
+
+// File is the Store in the file encrypted with AES-GCM
+// with the key derived from the passphrase with scrypt.
+// It is useful where the keyring is not available, e.g. on headless Linux.
+type File struct {
+	// Path is the path to the file.
+	Path string
+	// Passphrase returns the passphrase of the file.
+	Passphrase func() (string, error)
+	// NewPassphrase returns the passphrase of the file, when it is created,
+	// e.g. asking it twice to confirm. Passphrase is used, if it is nil.
+	NewPassphrase func() (string, error)
+
+	passphrase string
+	// exists is set, when the file is loaded.
+	exists bool
+}
+
+// sealed is the content of the file.
+type sealed struct {
+	Salt  []byte `json:"salt"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+// Get returns the password of the user.
+func (f *File) Get(key string) (string, error) {
+	passwords, err := f.load()
+	if err != nil {
+		return "", err
+	}
+	password, ok := passwords[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return password, nil
+}
+
+// Set saves the password of the user.
+func (f *File) Set(key, password string) error {
+	passwords, err := f.load()
+	if err != nil {
+		return err
+	}
+	passwords[key] = password
+	return f.save(passwords)
+}
+
+// Delete removes the password of the user.
+func (f *File) Delete(key string) error {
+	passwords, err := f.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := passwords[key]; !ok {
+		return ErrNotFound
+	}
+	delete(passwords, key)
+	return f.save(passwords)
+}
+
+func (f *File) String() string {
+	return fmt.Sprintf("the encrypted file %s", f.Path)
+}
+
+// getPassphrase returns the passphrase, asking it once.
+// NewPassphrase is asked, if the file does not exist.
+func (f *File) getPassphrase() (string, error) {
+	if f.passphrase != "" {
+		return f.passphrase, nil
+	}
+	ask := f.Passphrase
+	if !f.exists && f.NewPassphrase != nil {
+		ask = f.NewPassphrase
+	}
+	if ask == nil {
+		return "", errors.New("no passphrase of the encrypted file")
+	}
+	passphrase, err := ask()
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", errors.New("empty passphrase of the encrypted file")
+	}
+	f.passphrase = passphrase
+	return passphrase, nil
+}
+
+// load reads and decrypts passwords from the file.
+// There are no passwords, if the file does not exist.
+func (f *File) load() (map[string]string, error) {
+	passwords := make(map[string]string)
+	data, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return passwords, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	f.exists = true
+
+	var s sealed
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("can't parse %s: %w", f.Path, err)
+	}
+	aead, err := f.cipher(s.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := aead.Open(nil, s.Nonce, s.Data, nil)
+	if err != nil {
+		return nil, ErrPassphrase
+	}
+	if err := json.Unmarshal(plain, &passwords); err != nil {
+		return nil, fmt.Errorf("can't parse %s: %w", f.Path, err)
+	}
+	return passwords, nil
+}
+
+// save encrypts and writes passwords into the file readable by the user only.
+func (f *File) save(passwords map[string]string) error {
+	plain, err := json.Marshal(passwords)
+	if err != nil {
+		return err
+	}
+
+	s := sealed{Salt: make([]byte, saltSize)}
+	if _, err := rand.Read(s.Salt); err != nil {
+		return err
+	}
+	aead, err := f.cipher(s.Salt)
+	if err != nil {
+		return err
+	}
+	s.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(s.Nonce); err != nil {
+		return err
+	}
+	s.Data = aead.Seal(nil, s.Nonce, plain, nil)
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0700); err != nil {
+		return err
+	}
+	// the new file is created with 0600,
+	// a file left by the interrupted save may be readable by others.
+	tmp, err := ioutil.TempFile(filepath.Dir(f.Path), filepath.Base(f.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), f.Path); err != nil {
+		return err
+	}
+	f.exists = true
+	return nil
+}
+
+// cipher returns AES-GCM with the key derived from the passphrase and salt.
+func (f *File) cipher(salt []byte) (cipher.AEAD, error) {
+	passphrase, err := f.getPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keyLength)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,152 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package credstore
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tempFile returns the File in the temporary directory with the passphrase.
+// The directory should be removed by the test.
+func tempFile(t *testing.T, passphrase string) (*File, string) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "credstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fileWith(filepath.Join(dir, "credentials"), passphrase), dir
+}
+
+// fileWith returns the File at path with the passphrase.
+func fileWith(path, passphrase string) *File {
+	return &File{
+		Path:       path,
+		Passphrase: func() (string, error) { return passphrase, nil },
+	}
+}
+
+func TestFileRoundTrip(t *testing.T) {
+	f, dir := tempFile(t, "passphrase")
+	defer os.RemoveAll(dir)
+
+	if _, err := f.Get("alice@localhost:7777"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of the missing file: got %v, want ErrNotFound", err)
+	}
+	for key, password := range map[string]string{"alice@localhost:7777": "secret", "bob@localhost:7777": "other"} {
+		if err := f.Set(key, password); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+
+	data, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") || strings.Contains(string(data), "alice") {
+		t.Errorf("credentials are not encrypted: %s", data)
+	}
+
+	// the file is decrypted by another instance with the same passphrase.
+	f = fileWith(f.Path, "passphrase")
+	if password, err := f.Get("alice@localhost:7777"); err != nil || password != "secret" {
+		t.Errorf("Get: got %q, %v, want %q", password, err, "secret")
+	}
+	if err := f.Delete("alice@localhost:7777"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := f.Delete("alice@localhost:7777"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("the second Delete: got %v, want ErrNotFound", err)
+	}
+	f = fileWith(f.Path, "passphrase")
+	if _, err := f.Get("alice@localhost:7777"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of the deleted password: got %v, want ErrNotFound", err)
+	}
+	if password, err := f.Get("bob@localhost:7777"); err != nil || password != "other" {
+		t.Errorf("Get: got %q, %v, want %q", password, err, "other")
+	}
+}
+
+func TestFileWrongPassphrase(t *testing.T) {
+	f, dir := tempFile(t, "passphrase")
+	defer os.RemoveAll(dir)
+	if err := f.Set("alice@localhost:7777", "secret"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	f = fileWith(f.Path, "wrong")
+	if _, err := f.Get("alice@localhost:7777"); !errors.Is(err, ErrPassphrase) {
+		t.Errorf("Get: got %v, want ErrPassphrase", err)
+	}
+	if err := f.Set("bob@localhost:7777", "other"); !errors.Is(err, ErrPassphrase) {
+		t.Errorf("Set: got %v, want ErrPassphrase", err)
+	}
+}
+
+func TestFileNewPassphrase(t *testing.T) {
+	f, dir := tempFile(t, "passphrase")
+	defer os.RemoveAll(dir)
+	asked := 0
+	f.Passphrase = func() (string, error) {
+		return "", errors.New("the passphrase of the existing file is asked")
+	}
+	f.NewPassphrase = func() (string, error) {
+		asked++
+		return "passphrase", nil
+	}
+	if err := f.Set("alice@localhost:7777", "secret"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := f.Set("bob@localhost:7777", "other"); err != nil {
+		t.Fatalf("the second Set: %v", err)
+	}
+	if asked != 1 {
+		t.Errorf("the new passphrase is asked %d times, want once", asked)
+	}
+
+	// the passphrase of the existing file is not a new one.
+	f = fileWith(f.Path, "passphrase")
+	f.NewPassphrase = func() (string, error) {
+		return "", errors.New("the new passphrase is asked")
+	}
+	if _, err := f.Get("alice@localhost:7777"); err != nil {
+		t.Errorf("Get: %v", err)
+	}
+}
+
+func TestFileMode(t *testing.T) {
+	f, dir := tempFile(t, "passphrase")
+	defer os.RemoveAll(dir)
+	// the file left by the interrupted save is readable by others.
+	if err := ioutil.WriteFile(f.Path+".tmp", []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Set("alice@localhost:7777", "secret"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("got mode %v, want 0600", perm)
+	}
+}
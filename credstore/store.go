@@ -0,0 +1,97 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package credstore keeps passwords of users of grpc_server
+// in the keyring of the OS or in an encrypted file.
+package credstore
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zalando/go-keyring"
+)
+
+// service is the name of the application in the keyring.
+const service = "yagogame grpc_client"
+
+// ErrNotFound is returned when there is no password of the user.
+var ErrNotFound = errors.New("credentials not found")
+
+// Store keeps passwords of users.
+// Users are identified with keys, see Key.
+type Store interface {
+	// Get returns the password of the user.
+	Get(key string) (string, error)
+	// Set saves the password of the user.
+	Set(key, password string) error
+	// Delete removes the password of the user.
+	Delete(key string) error
+	// String describes the store to the user.
+	String() string
+}
+
+// Key returns the key of the user with login on the server with address.
+func Key(login, address string) string {
+	return fmt.Sprintf("%s@%s", login, address)
+}
+
+// Keyring is the Store in the keyring of the OS,
+// e.g. the Secret Service on Linux.
+type Keyring struct{}
+
+// Get returns the password of the user.
+func (Keyring) Get(key string) (string, error) {
+	password, err := keyring.Get(service, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNotFound
+	}
+	return password, err
+}
+
+// Set saves the password of the user.
+func (Keyring) Set(key, password string) error {
+	return keyring.Set(service, key, password)
+}
+
+// Delete removes the password of the user.
+func (Keyring) Delete(key string) error {
+	err := keyring.Delete(service, key)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (Keyring) String() string {
+	return "the keyring"
+}
+
+// Available reports whether the keyring may be used,
+// e.g. it is not on headless Linux without the Secret Service.
+func (k Keyring) Available() bool {
+	_, err := k.Get(Key("", "probe"))
+	return err == nil || errors.Is(err, ErrNotFound)
+}
+
+// Open returns the keyring, if it is available,
+// or the encrypted file otherwise.
+func Open(file *File) Store {
+	if k := (Keyring{}); k.Available() {
+		return k
+	}
+	return file
+}
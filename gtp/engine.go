@@ -0,0 +1,215 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package gtp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+	"github.com/yagoggame/grpc_client/client"
+)
+
+// quitTimeout is the time given to the engine to quit before it is killed.
+// It is a variable to be shortened by tests.
+var quitTimeout = 5 * time.Second
+
+// Engine is the client.MoveEngine asking the GTP engine for moves.
+// Stones placed since the previous move are played in the engine
+// and the engine is asked with genmove.
+type Engine struct {
+	conn *Conn
+	cmd  *exec.Cmd
+	in   io.Closer
+
+	// position is the board known to the engine, nil before the first game.
+	position *board.Board
+	komi     float64
+	// colour is the colour of the bot's stones.
+	colour board.Colour
+}
+
+var (
+	_ client.MoveEngine   = (*Engine)(nil)
+	_ client.ColourSetter = (*Engine)(nil)
+)
+
+// Start runs the engine with the command line, e.g. "gnugo --mode gtp".
+// Arguments are separated with spaces, quotes are not supported.
+// The engine should respond to every command in timeout, 0 means no limit.
+// The standard error of the engine is written to stderr.
+func Start(command string, timeout time.Duration, stderr io.Writer) (*Engine, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, errors.New("gtp engine command is empty")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("can't start gtp engine: %w", err)
+	}
+
+	conn := NewConn(in, out)
+	conn.SetTimeout(timeout)
+	e := &Engine{conn: conn, cmd: cmd, in: in}
+	if _, err := e.conn.Command("protocol_version"); err != nil {
+		e.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+// NewEngine returns the Engine talking to the engine over conn,
+// e.g. running in the same process or reached with the network.
+func NewEngine(conn *Conn) *Engine {
+	return &Engine{conn: conn}
+}
+
+// Close asks the engine to quit and waits for it.
+// The engine is killed, if it does not quit in time.
+func (e *Engine) Close() error {
+	if e.cmd == nil {
+		return nil
+	}
+	done := make(chan error, 1)
+	go func() {
+		e.conn.Command("quit")
+		e.in.Close()
+		done <- e.cmd.Wait()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(quitTimeout):
+		e.cmd.Process.Kill()
+		return <-done
+	}
+}
+
+// SetColour sets the colour of stones the engine generates moves for.
+func (e *Engine) SetColour(colour board.Colour) {
+	e.colour = colour
+}
+
+// NextMove plays the stones placed since the previous move in the engine
+// and returns the move generated by it.
+// Pass and resignation of the engine are reported with client.ErrNoMove.
+func (e *Engine) NextMove(state *api.State) (x, y int, err error) {
+	colour := e.colour
+	if colour == board.Empty {
+		return 0, 0, errors.New("the colour of stones is not set")
+	}
+	if err := e.sync(state); err != nil {
+		return 0, 0, err
+	}
+
+	response, err := e.conn.Command("genmove " + colour.String())
+	if err != nil {
+		return 0, 0, err
+	}
+	switch strings.ToLower(response) {
+	case "pass", "resign":
+		return 0, 0, fmt.Errorf("engine answers %s: %w", strings.ToLower(response), client.ErrNoMove)
+	}
+	p, err := board.ParseNotation(response, e.position.Size())
+	if err != nil {
+		return 0, 0, fmt.Errorf("wrong move of the engine: %w", err)
+	}
+	if _, err := e.position.Play(colour, p); err != nil {
+		// the engine keeps the move, the position is set up again the next time.
+		e.position = nil
+	}
+	return p.X, p.Y, nil
+}
+
+// sync brings the position of the engine to the state.
+// New stones are played in the engine one by one, so captures and ko are known to it.
+// The position is set up from scratch, when it can't be reached so,
+// e.g. in a new game.
+func (e *Engine) sync(state *api.State) error {
+	target := board.FromState(state)
+	if e.position == nil || e.position.Size() != target.Size() || e.komi != state.GetKomi() {
+		if err := e.newGame(state); err != nil {
+			return err
+		}
+	}
+
+	added, _ := target.Diff(e.position)
+	if e.playStones(target, added) == nil && same(e.position, target) {
+		return nil
+	}
+
+	if _, err := e.conn.Command("clear_board"); err != nil {
+		return err
+	}
+	e.position = board.New(target.Size())
+	// stones of the legal position are never captured, whatever the order is.
+	var stones []board.Point
+	stones = append(stones, target.Stones(board.Black)...)
+	stones = append(stones, target.Stones(board.White)...)
+	return e.playStones(target, stones)
+}
+
+// newGame sets the size of the board and komi of the state.
+func (e *Engine) newGame(state *api.State) error {
+	if _, err := e.conn.Command(fmt.Sprintf("boardsize %d", state.GetSize())); err != nil {
+		return err
+	}
+	if _, err := e.conn.Command("clear_board"); err != nil {
+		return err
+	}
+	if _, err := e.conn.Command(fmt.Sprintf("komi %g", state.GetKomi())); err != nil {
+		return err
+	}
+	e.position = board.New(int(state.GetSize()))
+	e.komi = state.GetKomi()
+	return nil
+}
+
+// playStones plays stones of colours given by target in the engine.
+func (e *Engine) playStones(target *board.Board, stones []board.Point) error {
+	size := target.Size()
+	for _, p := range stones {
+		colour := target.At(p)
+		if _, err := e.conn.Command(fmt.Sprintf("play %s %s", colour, p.Notation(size))); err != nil {
+			return err
+		}
+		if _, err := e.position.Play(colour, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// same reports whether boards have the same stones.
+func same(a, b *board.Board) bool {
+	added, removed := a.Diff(b)
+	return len(added) == 0 && len(removed) == 0
+}
@@ -0,0 +1,287 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package gtp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+	"github.com/yagoggame/grpc_client/client"
+)
+
+// stubEnv selects the mode of the test binary started as the stub engine.
+const stubEnv = "GTP_STUB"
+
+// Modes of the stub engine.
+const (
+	stubServe = "serve"
+	// stubHang doesn't quit, until it is killed.
+	stubHang = "hang"
+	// stubThink never responds to genmove.
+	stubThink = "think"
+)
+
+// TestMain runs the stub engine, when the test binary is started by Start.
+func TestMain(m *testing.M) {
+	switch os.Getenv(stubEnv) {
+	case stubServe:
+		(&stub{}).serve(os.Stdin, os.Stdout)
+		os.Exit(0)
+	case stubHang:
+		(&stub{hang: true}).serve(os.Stdin, os.Stdout)
+		time.Sleep(time.Hour)
+		os.Exit(0)
+	case stubThink:
+		(&stub{think: true}).serve(os.Stdin, os.Stdout)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// stub is the GTP engine for tests.
+// It keeps the board and answers genmove with the moves given to it,
+// pass is answered, when they are exhausted.
+type stub struct {
+	hang  bool
+	think bool
+
+	mu       sync.Mutex
+	moves    []string
+	commands []string
+	board    *board.Board
+}
+
+// serve answers commands read from r, until quit or the end of r.
+func (s *stub) serve(r io.Reader, w io.Writer) {
+	s.board = board.New(19)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if s.think && strings.HasPrefix(scanner.Text(), "genmove") {
+			continue
+		}
+		response, err := s.answer(scanner.Text())
+		if err != nil {
+			fmt.Fprintf(w, "? %s\n\n", err)
+			continue
+		}
+		fmt.Fprintf(w, "= %s\n\n", response)
+		if scanner.Text() == "quit" && !s.hang {
+			return
+		}
+	}
+}
+
+func (s *stub) answer(command string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands = append(s.commands, command)
+
+	fields := strings.Fields(command)
+	switch fields[0] {
+	case "protocol_version":
+		return "2", nil
+	case "boardsize":
+		size, err := strconv.Atoi(fields[1])
+		s.board = board.New(size)
+		return "", err
+	case "clear_board":
+		s.board = board.New(s.board.Size())
+		return "", nil
+	case "komi", "quit":
+		return "", nil
+	case "play":
+		p, err := board.ParseNotation(fields[2], s.board.Size())
+		if err == nil {
+			_, err = s.board.Play(stubColour(fields[1]), p)
+		}
+		return "", err
+	case "genmove":
+		if len(s.moves) == 0 {
+			return "pass", nil
+		}
+		move := s.moves[0]
+		s.moves = s.moves[1:]
+		return move, nil
+	}
+	return "", errors.New("unknown command")
+}
+
+// sent returns commands received since the previous call.
+func (s *stub) sent() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	commands := s.commands
+	s.commands = nil
+	return commands
+}
+
+func stubColour(s string) board.Colour {
+	if strings.HasPrefix(strings.ToLower(s), "b") {
+		return board.Black
+	}
+	return board.White
+}
+
+// newStubEngine returns the Engine talking to the stub over pipes.
+// The stub is stopped with the returned function.
+func newStubEngine(moves ...string) (*Engine, *stub, func()) {
+	commandsR, commandsW := io.Pipe()
+	responsesR, responsesW := io.Pipe()
+	s := &stub{moves: moves}
+	go func() {
+		s.serve(commandsR, responsesW)
+		responsesW.Close()
+	}()
+	return NewEngine(NewConn(commandsW, responsesR)), s, func() { commandsW.Close() }
+}
+
+// newState returns the state of the game on the board of the size with the stones.
+func newState(size int, black, white []board.Point) *api.State {
+	chips := func(points []board.Point) []*api.TurnMessage {
+		var rez []*api.TurnMessage
+		for _, p := range points {
+			rez = append(rez, &api.TurnMessage{X: int64(p.X), Y: int64(p.Y)})
+		}
+		return rez
+	}
+	return &api.State{
+		Size:  int64(size),
+		Komi:  0.5,
+		Black: &api.State_ColourState{ChipsOnBoard: chips(black)},
+		White: &api.State_ColourState{ChipsOnBoard: chips(white)},
+	}
+}
+
+// checkSent compares commands received by the stub with the expected ones.
+func checkSent(t *testing.T, s *stub, want ...string) {
+	t.Helper()
+	if got := s.sent(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got commands %q, want %q", got, want)
+	}
+}
+
+func TestEngineSync(t *testing.T) {
+	e, s, stop := newStubEngine("C3", "D4", "B2")
+	defer stop()
+	e.SetColour(board.Black)
+	// the first row is the top one: A5 and E1 on the board 5x5.
+	a5, c3, e1 := board.Point{X: 1, Y: 1}, board.Point{X: 3, Y: 3}, board.Point{X: 5, Y: 5}
+
+	x, y, err := e.NextMove(newState(5, nil, []board.Point{a5}))
+	if err != nil || x != c3.X || y != c3.Y {
+		t.Fatalf("NextMove: got %d %d %v, want C3", x, y, err)
+	}
+	checkSent(t, s, "boardsize 5", "clear_board", "komi 0.5", "play white A5", "genmove black")
+
+	// only the stone placed since the previous move is played.
+	if _, _, err := e.NextMove(newState(5, []board.Point{c3}, []board.Point{a5, e1})); err != nil {
+		t.Fatalf("NextMove: %v", err)
+	}
+	checkSent(t, s, "play white E1", "genmove black")
+
+	// the position, which can't be reached with moves, is set up again.
+	if _, _, err := e.NextMove(newState(5, []board.Point{c3}, []board.Point{e1})); err != nil {
+		t.Fatalf("NextMove: %v", err)
+	}
+	checkSent(t, s, "clear_board", "play black C3", "play white E1", "genmove black")
+}
+
+func TestEngineNoMove(t *testing.T) {
+	e, _, stop := newStubEngine("pass", "resign")
+	defer stop()
+	e.SetColour(board.Black)
+	state := newState(5, nil, nil)
+
+	for _, answer := range []string{"pass", "resign"} {
+		if _, _, err := e.NextMove(state); !errors.Is(err, client.ErrNoMove) {
+			t.Errorf("%s: got %v, want ErrNoMove", answer, err)
+		}
+	}
+}
+
+func TestEngineWrongMove(t *testing.T) {
+	e, _, stop := newStubEngine("Z99")
+	defer stop()
+	e.SetColour(board.Black)
+
+	if _, _, err := e.NextMove(newState(5, nil, nil)); err == nil {
+		t.Error("wrong move of the engine: error expected")
+	}
+}
+
+// startStub starts the test binary as the stub engine in the mode.
+func startStub(t *testing.T, mode string, timeout time.Duration) *Engine {
+	t.Helper()
+	os.Setenv(stubEnv, mode)
+	defer os.Unsetenv(stubEnv)
+	e, err := Start(os.Args[0]+" -test.run=^$", timeout, ioutil.Discard)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	return e
+}
+
+func TestStartAndClose(t *testing.T) {
+	e := startStub(t, stubServe, DefaultTimeout)
+	e.SetColour(board.Black)
+	if x, y, err := e.NextMove(newState(5, nil, nil)); err == nil {
+		t.Errorf("NextMove: got %d %d, want pass", x, y)
+	}
+	if err := e.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
+
+func TestCloseKillsHangingEngine(t *testing.T) {
+	defer func(timeout time.Duration) { quitTimeout = timeout }(quitTimeout)
+	quitTimeout = 100 * time.Millisecond
+
+	e := startStub(t, stubHang, DefaultTimeout)
+	start := time.Now()
+	if err := e.Close(); err == nil {
+		t.Error("Close of the killed engine: error expected")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Close took %s", elapsed)
+	}
+}
+
+func TestEngineTimeout(t *testing.T) {
+	e := startStub(t, stubThink, 100*time.Millisecond)
+	e.SetColour(board.Black)
+	start := time.Now()
+	if _, _, err := e.NextMove(newState(5, nil, nil)); !errors.Is(err, ErrTimeout) {
+		t.Errorf("NextMove: got %v, want ErrTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("NextMove took %s", elapsed)
+	}
+	if err := e.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package gtp runs Go engines speaking the Go Text Protocol,
+// e.g. GNU Go or KataGo, to choose moves of the bot.
+package gtp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is the default time limit of the response to a command.
+const DefaultTimeout = time.Minute
+
+// ErrTimeout is returned, when the engine does not respond to the command in time.
+var ErrTimeout = errors.New("gtp engine does not respond in time")
+
+// Error is a failure response of the engine.
+type Error struct {
+	// Command is the failed command.
+	Command string
+	// Message is the error message of the engine.
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("gtp command %q failed: %s", e.Command, e.Message)
+}
+
+// Conn sends commands to the engine and reads its responses.
+// It is safe to use from several goroutines, commands are sent one at a time.
+type Conn struct {
+	mu      sync.Mutex
+	w       io.Writer
+	r       *bufio.Reader
+	timeout time.Duration
+	// broken is the error of the command, which is not responded in time.
+	// The late response can't be told from the next ones, so the Conn is not used anymore.
+	broken error
+}
+
+// NewConn returns the Conn sending commands to w and reading responses from r.
+// Responses are waited for DefaultTimeout.
+func NewConn(w io.Writer, r io.Reader) *Conn {
+	return &Conn{w: w, r: bufio.NewReader(r), timeout: DefaultTimeout}
+}
+
+// SetTimeout sets the time limit of the response to a command, 0 means no limit.
+func (c *Conn) SetTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timeout = timeout
+}
+
+// Command sends the command and returns the response without the status.
+// The failure response is returned as *Error.
+// ErrTimeout is returned, if the response is not read in time,
+// and the Conn fails all the next commands with it.
+func (c *Conn) Command(command string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.broken != nil {
+		return "", c.broken
+	}
+
+	// the engine may hang on writing as well as on reading.
+	type result struct {
+		status   byte
+		response string
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		status, response, err := c.exchange(command)
+		done <- result{status, response, err}
+	}()
+
+	var timeout <-chan time.Time
+	if c.timeout > 0 {
+		timer := time.NewTimer(c.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	var res result
+	select {
+	case res = <-done:
+	case <-timeout:
+		c.broken = fmt.Errorf("no response to gtp command %q in %s: %w", command, c.timeout, ErrTimeout)
+		return "", c.broken
+	}
+
+	if res.err != nil {
+		return "", res.err
+	}
+	if res.status == '?' {
+		return "", &Error{Command: command, Message: res.response}
+	}
+	return res.response, nil
+}
+
+// exchange sends the command and reads the response.
+func (c *Conn) exchange(command string) (status byte, response string, err error) {
+	if _, err := fmt.Fprintf(c.w, "%s\n", command); err != nil {
+		return 0, "", fmt.Errorf("can't send gtp command %q: %w", command, err)
+	}
+	status, response, err = c.read()
+	if err != nil {
+		return 0, "", fmt.Errorf("can't read response to gtp command %q: %w", command, err)
+	}
+	return status, response, nil
+}
+
+// read reads the response, which is finished with an empty line.
+// Comments and empty lines before the response are skipped.
+func (c *Conn) read() (status byte, response string, err error) {
+	var lines []string
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, "", err
+		}
+		line = strings.TrimRight(strings.Replace(line, "\t", " ", -1), "\r\n")
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		switch {
+		case len(lines) == 0 && strings.TrimSpace(line) == "":
+			continue
+		case line == "" || err == io.EOF:
+			if line != "" {
+				lines = append(lines, line)
+			}
+			return parseResponse(lines)
+		}
+		lines = append(lines, line)
+	}
+}
+
+// parseResponse splits the status and the optional id from the response.
+func parseResponse(lines []string) (status byte, response string, err error) {
+	first := lines[0]
+	if first[0] != '=' && first[0] != '?' {
+		return 0, "", fmt.Errorf("wrong gtp response %q", first)
+	}
+	status = first[0]
+	// the id of the command follows the status, commands are sent without ids.
+	lines[0] = strings.TrimLeft(first[1:], "0123456789")
+	return status, strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
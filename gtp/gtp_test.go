@@ -0,0 +1,117 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package gtp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConnCommand(t *testing.T) {
+	responses := strings.Join([]string{
+		"= 2\n\n",
+		"# comment before the response\n\n=1 GNU Go # the name\n\n",
+		"= A1 B2\r\nC3\r\n\r\n",
+		"? unknown command\n\n",
+		"=\tlast without the empty line",
+	}, "")
+	var sent bytes.Buffer
+	c := NewConn(&sent, strings.NewReader(responses))
+
+	tests := []struct {
+		command, response string
+	}{
+		{"protocol_version", "2"},
+		{"name", "GNU Go"},
+		{"list_stones black", "A1 B2\nC3"},
+	}
+	for _, test := range tests {
+		response, err := c.Command(test.command)
+		if err != nil || response != test.response {
+			t.Errorf("%s: got %q, %v, want %q", test.command, response, err, test.response)
+		}
+	}
+
+	_, err := c.Command("foo")
+	var gtpErr *Error
+	if !errors.As(err, &gtpErr) || gtpErr.Command != "foo" || gtpErr.Message != "unknown command" {
+		t.Errorf("foo: got %v, want the failure response", err)
+	}
+	if response, err := c.Command("last"); err != nil || response != "last without the empty line" {
+		t.Errorf("last: got %q, %v", response, err)
+	}
+	if _, err := c.Command("more"); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("after the end: got %v, want io.ErrUnexpectedEOF", err)
+	}
+
+	want := "protocol_version\nname\nlist_stones black\nfoo\nlast\nmore\n"
+	if sent.String() != want {
+		t.Errorf("sent %q, want %q", sent.String(), want)
+	}
+}
+
+func TestConnTimeout(t *testing.T) {
+	// nothing is written to the pipe, the engine never responds.
+	r, w := io.Pipe()
+	defer w.Close()
+	c := NewConn(ioutil.Discard, r)
+	c.SetTimeout(50 * time.Millisecond)
+
+	start := time.Now()
+	if _, err := c.Command("genmove black"); !errors.Is(err, ErrTimeout) {
+		t.Fatalf("genmove: got %v, want ErrTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("genmove took %s", elapsed)
+	}
+
+	// the late response must not be taken for the response to the next command.
+	go w.Write([]byte("= C3\n\n"))
+	if _, err := c.Command("name"); !errors.Is(err, ErrTimeout) {
+		t.Errorf("the next command: got %v, want ErrTimeout", err)
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	tests := []struct {
+		lines    []string
+		status   byte
+		response string
+		wrong    bool
+	}{
+		{lines: []string{"="}, status: '='},
+		{lines: []string{"= C3"}, status: '=', response: "C3"},
+		{lines: []string{"=12 C3"}, status: '=', response: "C3"},
+		{lines: []string{"=", "A1", "B2"}, status: '=', response: "A1\nB2"},
+		{lines: []string{"?3 illegal move"}, status: '?', response: "illegal move"},
+		{lines: []string{"C3"}, wrong: true},
+	}
+	for _, test := range tests {
+		status, response, err := parseResponse(test.lines)
+		switch {
+		case test.wrong && err == nil:
+			t.Errorf("%q: error expected", test.lines)
+		case !test.wrong && (err != nil || status != test.status || response != test.response):
+			t.Errorf("%q: got %c %q %v, want %c %q", test.lines, status, response, err, test.status, test.response)
+		}
+	}
+}
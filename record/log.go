@@ -0,0 +1,136 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+)
+
+// logFormat identifies the game log of the client.
+const logFormat = "yagogame-log/1"
+
+// maxLogLine is the maximal length of a line of the game log.
+const maxLogLine = 1 << 20
+
+// logHeader is the first line of the game log,
+// the states obtained from the server follow it, one JSON object per line.
+type logHeader struct {
+	Format  string       `json:"format"`
+	Started time.Time    `json:"started"`
+	Colour  board.Colour `json:"colour"`
+	Black   string       `json:"black,omitempty"`
+	White   string       `json:"white,omitempty"`
+}
+
+// WriteLog writes the game in the log format of the client,
+// which keeps all the states as they were obtained from the server.
+func (g *Game) WriteLog(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	header, err := json.Marshal(&logHeader{
+		Format:  logFormat,
+		Started: g.Started,
+		Colour:  g.Colour,
+		Black:   g.Black,
+		White:   g.White,
+	})
+	if err != nil {
+		return err
+	}
+	bw.Write(header)
+	bw.WriteByte('\n')
+
+	m := &jsonpb.Marshaler{}
+	for _, state := range g.states {
+		if err := m.Marshal(bw, state); err != nil {
+			return err
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// ReadLog reads the game in the log format of the client.
+func ReadLog(r io.Reader) (*Game, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLogLine)
+
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("empty game log")
+	}
+	header := &logHeader{}
+	if err := json.Unmarshal(scanner.Bytes(), header); err != nil || header.Format != logFormat {
+		return nil, fmt.Errorf("wrong game log header")
+	}
+
+	g := &Game{
+		Started: header.Started,
+		Colour:  header.Colour,
+		Black:   header.Black,
+		White:   header.White,
+	}
+	for line := 2; scanner.Scan(); line++ {
+		state := &api.State{}
+		if err := jsonpb.Unmarshal(bytes.NewReader(scanner.Bytes()), state); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := checkState(state); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(g.states) > 0 && state.GetSize() != g.states[0].GetSize() {
+			return nil, fmt.Errorf("line %d: size %d differs from size %d of the game",
+				line, state.GetSize(), g.states[0].GetSize())
+		}
+		g.add(state)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(g.states) == 0 {
+		return nil, fmt.Errorf("no states in game log")
+	}
+	return g, nil
+}
+
+// Read reads the game either in SGF or in the log format of the client.
+func Read(r io.Reader) (*Game, error) {
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return nil, fmt.Errorf("unknown format of the game record")
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			br.ReadByte()
+		case '(':
+			return ReadSGF(br)
+		case '{':
+			return ReadLog(br)
+		default:
+			return nil, fmt.Errorf("unknown format of the game record")
+		}
+	}
+}
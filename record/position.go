@@ -0,0 +1,77 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package record
+
+import (
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+)
+
+// position is a position of the game being reconstructed from moves.
+type position struct {
+	board    *board.Board
+	komi     float64
+	captured map[board.Colour]int
+}
+
+func newPosition(size int, komi float64) *position {
+	return &position{
+		board:    board.New(size),
+		komi:     komi,
+		captured: make(map[board.Colour]int),
+	}
+}
+
+// play places the stone and counts captured stones.
+func (pos *position) play(c board.Colour, p board.Point) error {
+	captured, err := pos.board.Play(c, p)
+	if err != nil {
+		return err
+	}
+	pos.captured[c] += len(captured)
+	return nil
+}
+
+// setup places the setup stone as is, captures and ko are not applied to it.
+func (pos *position) setup(c board.Colour, p board.Point) error {
+	if pos.board.At(p) != board.Empty {
+		return board.ErrOccupied
+	}
+	return pos.board.Put(c, p)
+}
+
+// state builds api.State of the position.
+// Chips in cup are unknown and scores are counted by captured stones only.
+func (pos *position) state() *api.State {
+	return &api.State{
+		Komi:  pos.komi,
+		Size:  int64(pos.board.Size()),
+		Black: pos.colourState(board.Black, 0),
+		White: pos.colourState(board.White, pos.komi),
+	}
+}
+
+func (pos *position) colourState(c board.Colour, komi float64) *api.State_ColourState {
+	cs := &api.State_ColourState{
+		ChipsCaptured: int64(pos.captured[c]),
+		Scores:        float64(pos.captured[c]) + komi,
+	}
+	for _, p := range pos.board.Stones(c) {
+		cs.ChipsOnBoard = append(cs.ChipsOnBoard, &api.TurnMessage{X: int64(p.X), Y: int64(p.Y)})
+	}
+	return cs
+}
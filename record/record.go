@@ -0,0 +1,212 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package record keeps the history of a game and exports it in SGF.
+package record
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+)
+
+// Move is a stone placed during the game.
+type Move struct {
+	Colour board.Colour
+	Point  board.Point
+}
+
+// Game is a record of the game as seen by the user.
+type Game struct {
+	// Started is the time the game is started.
+	Started time.Time
+	// Colour is the colour of the user's stones, Empty if unknown yet.
+	Colour board.Colour
+	// Black is the name of the black player, empty if unknown.
+	Black string
+	// White is the name of the white player, empty if unknown.
+	White string
+
+	states []*api.State
+	moves  []Move
+	// setup are stones placed before the moves, e.g. handicap stones of a game record.
+	setup  []Move
+	result string
+}
+
+// NewGame creates an empty record of the game started now.
+func NewGame() *Game {
+	return &Game{Started: time.Now()}
+}
+
+// Add appends the state obtained from the server.
+// The state equal to the last one is skipped.
+func (g *Game) Add(state *api.State) {
+	if state == nil {
+		return
+	}
+	if n := len(g.states); n > 0 && proto.Equal(g.states[n-1], state) {
+		return
+	}
+	g.add(state)
+}
+
+// add appends the state and the stones placed since the last state to the moves.
+func (g *Game) add(state *api.State) {
+	next := board.Black
+	if n := len(g.moves); n > 0 {
+		next = g.moves[n-1].Colour.Opponent()
+	}
+	g.moves = append(g.moves, placedMoves(g.Last(), state, next)...)
+	g.states = append(g.states, state)
+}
+
+// placedMoves returns the stones placed since the previous state in the order they were played.
+// Players take turns starting with the colour next. If several stones of the same colour
+// are placed, e.g. some states are missed, their order is unknown and they are ordered by rows.
+func placedMoves(prev, state *api.State, next board.Colour) []Move {
+	cur := board.FromState(state)
+	added, _ := cur.Diff(board.FromState(prev))
+	stones := make(map[board.Colour][]board.Point)
+	for _, p := range added {
+		stones[cur.At(p)] = append(stones[cur.At(p)], p)
+	}
+
+	var moves []Move
+	for len(stones[board.Black])+len(stones[board.White]) > 0 {
+		// the other player has passed.
+		if len(stones[next]) == 0 {
+			next = next.Opponent()
+		}
+		moves = append(moves, Move{Colour: next, Point: stones[next][0]})
+		stones[next] = stones[next][1:]
+		next = next.Opponent()
+	}
+	return moves
+}
+
+// AddOwn appends the state obtained after the user's turn.
+// It detects the colour of the user's stones.
+func (g *Game) AddOwn(state *api.State) {
+	if n := len(g.states); n > 0 && state != nil && g.Colour == board.Empty {
+		cur := board.FromState(state)
+		if added, _ := cur.Diff(board.FromState(g.states[n-1])); len(added) == 1 {
+			g.Colour = cur.At(added[0])
+		}
+	}
+	g.Add(state)
+}
+
+// UnknownPlayer is the name of the player whose login is unknown.
+const UnknownPlayer = "?"
+
+// PlayerName returns the name of the player or UnknownPlayer.
+func PlayerName(name string) string {
+	if name == "" {
+		return UnknownPlayer
+	}
+	return name
+}
+
+// MaxSize is the maximal size of the board of a game record.
+const MaxSize = 25
+
+// checkSize reports an error if the size of the board is not supported.
+func checkSize(size int) error {
+	if size < 1 || size > MaxSize {
+		return fmt.Errorf("wrong size %d", size)
+	}
+	return nil
+}
+
+// checkState reports an error if the state has a wrong size
+// or stones outside the board.
+func checkState(state *api.State) error {
+	size := state.GetSize()
+	if err := checkSize(int(size)); err != nil {
+		return err
+	}
+	for _, colour := range []*api.State_ColourState{state.GetBlack(), state.GetWhite()} {
+		for _, p := range colour.GetChipsOnBoard() {
+			if p.GetX() < 1 || p.GetY() < 1 || p.GetX() > size || p.GetY() > size {
+				return fmt.Errorf("stone (%d, %d) is out of the board", p.GetX(), p.GetY())
+			}
+		}
+	}
+	return nil
+}
+
+// SetPlayers sets names of the players according to the colour of the user.
+func (g *Game) SetPlayers(player, opponent string) {
+	switch g.Colour {
+	case board.Black:
+		g.Black, g.White = player, opponent
+	case board.White:
+		g.Black, g.White = opponent, player
+	}
+}
+
+// States returns states of the game.
+func (g *Game) States() []*api.State {
+	return g.states
+}
+
+// Last returns the last state of the game or nil, if the record is empty.
+func (g *Game) Last() *api.State {
+	if len(g.states) == 0 {
+		return nil
+	}
+	return g.states[len(g.states)-1]
+}
+
+// Size returns the size of the board.
+func (g *Game) Size() int {
+	return int(g.Last().GetSize())
+}
+
+// Komi returns komi of the game.
+func (g *Game) Komi() float64 {
+	return g.Last().GetKomi()
+}
+
+// Moves returns the sequence of moves reconstructed by comparing stones on the board
+// in consecutive states.
+func (g *Game) Moves() []Move {
+	return g.moves
+}
+
+// Result returns the result of the game in SGF notation,
+// e.g. "B+3.5", or "?" if the game is not over.
+func (g *Game) Result() string {
+	if g.result != "" {
+		return g.result
+	}
+	last := g.Last()
+	if !last.GetGameOver() {
+		return "?"
+	}
+	black, white := last.GetBlack().GetScores(), last.GetWhite().GetScores()
+	switch {
+	case black > white:
+		return fmt.Sprintf("B+%g", black-white)
+	case white > black:
+		return fmt.Sprintf("W+%g", white-black)
+	}
+	return "0"
+}
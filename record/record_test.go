@@ -0,0 +1,290 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package record
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+)
+
+// newState returns the state of the board with black and white stones.
+func newState(size int, black, white []board.Point) *api.State {
+	state := &api.State{
+		Size:  int64(size),
+		Komi:  0.5,
+		Black: &api.State_ColourState{},
+		White: &api.State_ColourState{Scores: 0.5},
+	}
+	for _, p := range black {
+		state.Black.ChipsOnBoard = append(state.Black.ChipsOnBoard, &api.TurnMessage{X: int64(p.X), Y: int64(p.Y)})
+	}
+	for _, p := range white {
+		state.White.ChipsOnBoard = append(state.White.ChipsOnBoard, &api.TurnMessage{X: int64(p.X), Y: int64(p.Y)})
+	}
+	return state
+}
+
+// points returns points of the moves.
+func points(moves []Move) []board.Point {
+	var rez []board.Point
+	for _, m := range moves {
+		rez = append(rez, m.Point)
+	}
+	return rez
+}
+
+func TestMovesFollowPlayOrder(t *testing.T) {
+	g := NewGame()
+	g.Add(newState(5, nil, nil))
+	g.Add(newState(5, []board.Point{{X: 5, Y: 5}}, nil))
+	g.Add(newState(5, []board.Point{{X: 5, Y: 5}}, nil))
+	// the state of the opponent's turn is missed, the white stone is placed first
+	// and lies above the black one.
+	g.Add(newState(5, []board.Point{{X: 5, Y: 5}, {X: 3, Y: 3}}, []board.Point{{X: 1, Y: 1}}))
+	// white captures the stone at E1 with two moves in a row, black passes.
+	g.Add(newState(5, []board.Point{{X: 3, Y: 3}}, []board.Point{{X: 1, Y: 1}, {X: 5, Y: 4}, {X: 4, Y: 5}}))
+
+	want := []Move{
+		{Colour: board.Black, Point: board.Point{X: 5, Y: 5}},
+		{Colour: board.White, Point: board.Point{X: 1, Y: 1}},
+		{Colour: board.Black, Point: board.Point{X: 3, Y: 3}},
+		{Colour: board.White, Point: board.Point{X: 5, Y: 4}},
+		{Colour: board.White, Point: board.Point{X: 4, Y: 5}},
+	}
+	if got := g.Moves(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Moves: got %v, want %v", got, want)
+	}
+	if n := len(g.States()); n != 4 {
+		t.Errorf("got %d states, want 4 without the repeated one", n)
+	}
+}
+
+func TestAddOwnDetectsColour(t *testing.T) {
+	g := NewGame()
+	g.Add(newState(5, []board.Point{{X: 1, Y: 1}}, nil))
+	g.AddOwn(newState(5, []board.Point{{X: 1, Y: 1}}, []board.Point{{X: 2, Y: 2}}))
+	if g.Colour != board.White {
+		t.Fatalf("Colour: got %v, want white", g.Colour)
+	}
+	g.SetPlayers("alice", "bob")
+	if g.Black != "bob" || g.White != "alice" {
+		t.Errorf("players: got %s vs %s, want bob vs alice", g.Black, g.White)
+	}
+}
+
+func TestResult(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		gameOver     bool
+		black, white float64
+		want         string
+	}{
+		{"not over", false, 10, 0.5, "?"},
+		{"black wins", true, 10, 6.5, "B+3.5"},
+		{"white wins", true, 3, 6.5, "W+3.5"},
+		{"draw", true, 6, 6, "0"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			state := newState(9, nil, nil)
+			state.GameOver = tc.gameOver
+			state.Black.Scores, state.White.Scores = tc.black, tc.white
+			g := NewGame()
+			g.Add(state)
+			if got := g.Result(); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// recordedGame returns the game of two moves and a capture, finished with black's win.
+func recordedGame() *Game {
+	g := NewGame()
+	g.Started = time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+	g.Add(newState(5, nil, nil))
+	g.AddOwn(newState(5, []board.Point{{X: 2, Y: 1}}, nil))
+	g.Add(newState(5, []board.Point{{X: 2, Y: 1}}, []board.Point{{X: 1, Y: 1}}))
+	over := newState(5, []board.Point{{X: 2, Y: 1}, {X: 1, Y: 2}}, nil)
+	over.GameOver = true
+	over.Black.Scores = 3
+	g.AddOwn(over)
+	g.SetPlayers("ali]ce", `b\ob`)
+	return g
+}
+
+func TestWriteSGF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := recordedGame().WriteSGF(&buf); err != nil {
+		t.Fatalf("WriteSGF: %v", err)
+	}
+	want := "(;GM[1]FF[4]CA[UTF-8]AP[yagogame grpc_client]\n" +
+		"SZ[5]KM[0.5]DT[2020-03-01]\n" +
+		`PB[ali\]ce]PW[b\\ob]RE[B+2.5]` + "\n" +
+		";B[ba];W[aa];B[ab])\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSGFRoundTrip(t *testing.T) {
+	written := recordedGame()
+	var buf bytes.Buffer
+	if err := written.WriteSGF(&buf); err != nil {
+		t.Fatalf("WriteSGF: %v", err)
+	}
+	read, err := ReadSGF(&buf)
+	if err != nil {
+		t.Fatalf("ReadSGF: %v", err)
+	}
+
+	if read.Size() != written.Size() || read.Komi() != written.Komi() {
+		t.Errorf("board: got size %d komi %g, want size %d komi %g", read.Size(), read.Komi(), written.Size(), written.Komi())
+	}
+	if read.Black != written.Black || read.White != written.White || read.Result() != written.Result() {
+		t.Errorf("game info: got %q vs %q %s, want %q vs %q %s",
+			read.Black, read.White, read.Result(), written.Black, written.White, written.Result())
+	}
+	if !read.Started.Equal(written.Started.Truncate(24 * time.Hour)) {
+		t.Errorf("date: got %v, want %v", read.Started, written.Started)
+	}
+	if !reflect.DeepEqual(read.Moves(), written.Moves()) {
+		t.Errorf("moves: got %v, want %v", read.Moves(), written.Moves())
+	}
+	final := board.FromState(read.Last())
+	if got, want := final.Stones(board.Black), points([]Move{written.Moves()[0], written.Moves()[2]}); !reflect.DeepEqual(got, want) {
+		t.Errorf("black stones of the final position: got %v, want %v", got, want)
+	}
+	if got := final.Stones(board.White); len(got) != 0 {
+		t.Errorf("white stone is not captured: %v", got)
+	}
+}
+
+func TestReadSGF(t *testing.T) {
+	game, err := ReadSGF(strings.NewReader("(;SZ[9]KM[6.5]PB[alice]PW[bob]AB[cc];W[dd];B[ee];W[])"))
+	if err != nil {
+		t.Fatalf("ReadSGF: %v", err)
+	}
+	if game.Size() != 9 || game.Komi() != 6.5 || game.Black != "alice" || game.White != "bob" {
+		t.Errorf("wrong game: size %d, komi %g, %s vs %s", game.Size(), game.Komi(), game.Black, game.White)
+	}
+	// the setup stone is not a move, the pass is skipped.
+	want := []Move{
+		{Colour: board.White, Point: board.Point{X: 4, Y: 4}},
+		{Colour: board.Black, Point: board.Point{X: 5, Y: 5}},
+	}
+	if got := game.Moves(); !reflect.DeepEqual(got, want) {
+		t.Errorf("moves: got %v, want %v", got, want)
+	}
+	if n := len(game.States()); n != 4 {
+		t.Errorf("got %d states, want 4", n)
+	}
+}
+
+func TestReadSGFSetupStones(t *testing.T) {
+	// the white stone without liberties is neither captured nor a suicide.
+	sgf := "(;SZ[5]AW[aa]AB[ab][ba];W[cc])"
+	for i := 0; i < 2; i++ {
+		game, err := ReadSGF(strings.NewReader(sgf))
+		if err != nil {
+			t.Fatalf("ReadSGF(%s): %v", sgf, err)
+		}
+		final := board.FromState(game.Last())
+		if got, want := final.Stones(board.Black), []board.Point{{X: 2, Y: 1}, {X: 1, Y: 2}}; !reflect.DeepEqual(got, want) {
+			t.Errorf("black stones: got %v, want %v", got, want)
+		}
+		if got, want := final.Stones(board.White), []board.Point{{X: 1, Y: 1}, {X: 3, Y: 3}}; !reflect.DeepEqual(got, want) {
+			t.Errorf("white stones: got %v, want %v", got, want)
+		}
+		if n := len(game.Moves()); n != 1 {
+			t.Errorf("got %d moves, want 1", n)
+		}
+
+		// setup stones are kept, when the game is written, the unknown date is omitted.
+		var buf bytes.Buffer
+		if err := game.WriteSGF(&buf); err != nil {
+			t.Fatalf("WriteSGF: %v", err)
+		}
+		if !strings.Contains(buf.String(), "SZ[5]KM[0]\n") || !strings.Contains(buf.String(), "AB[ab][ba]\nAW[aa]\n;W[cc]") {
+			t.Errorf("setup stones are not written:\n%s", buf.String())
+		}
+		sgf = buf.String()
+	}
+}
+
+func TestReadSGFErrors(t *testing.T) {
+	tests := []struct {
+		name, sgf string
+	}{
+		{"negative size", "(;SZ[-1];B[aa])"},
+		{"zero size", "(;SZ[0])"},
+		{"huge size", "(;SZ[1000000000])"},
+		{"move out of board", "(;SZ[9];B[jj])"},
+		{"move before the board", "(;SZ[9];B[aA])"},
+		{"setup out of board", "(;SZ[9]AB[zz])"},
+		{"occupied setup", "(;SZ[9]AB[aa]AW[aa])"},
+		{"occupied move", "(;SZ[9];B[aa];W[aa])"},
+		{"wrong point", "(;SZ[9];B[abc])"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadSGF(strings.NewReader(test.sgf)); err == nil {
+				t.Errorf("ReadSGF(%s): error expected", test.sgf)
+			}
+		})
+	}
+}
+
+const logHeaderLine = `{"format":"yagogame-log/1","started":"2020-03-01T00:00:00Z","colour":1}` + "\n"
+
+func TestReadLog(t *testing.T) {
+	log := logHeaderLine +
+		`{"size":"9","black":{"chipsOnBoard":[{"x":"1","y":"1"}]}}` + "\n" +
+		`{"size":"9","black":{"chipsOnBoard":[{"x":"1","y":"1"}]},"white":{"chipsOnBoard":[{"x":"9","y":"9"}]}}` + "\n"
+	game, err := ReadLog(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("ReadLog: %v", err)
+	}
+	if n := len(game.States()); n != 2 {
+		t.Errorf("got %d states, want 2", n)
+	}
+}
+
+func TestReadLogErrors(t *testing.T) {
+	tests := []struct {
+		name, states string
+	}{
+		{"no states", ""},
+		{"negative size", `{"size":"-1"}`},
+		{"huge size", `{"size":"1000000000"}`},
+		{"stone out of board", `{"size":"9","black":{"chipsOnBoard":[{"x":"10","y":"1"}]}}`},
+		{"stone before the board", `{"size":"9","white":{"chipsOnBoard":[{"x":"0","y":"1"}]}}`},
+		{"changed size", `{"size":"9"}` + "\n" + `{"size":"13"}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadLog(strings.NewReader(logHeaderLine + test.states)); err == nil {
+				t.Errorf("ReadLog(%s): error expected", test.states)
+			}
+		})
+	}
+}
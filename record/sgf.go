@@ -0,0 +1,322 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package record
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/yagoggame/grpc_client/board"
+)
+
+// sgfColours are SGF property names of moves and setup stones,
+// in the order they are written and read.
+var sgfColours = []struct {
+	colour       board.Colour
+	move, stones string
+}{
+	{board.Black, "B", "AB"},
+	{board.White, "W", "AW"},
+}
+
+// sgfMove returns SGF property name of the move of the colour.
+func sgfMove(colour board.Colour) string {
+	for _, c := range sgfColours {
+		if c.colour == colour {
+			return c.move
+		}
+	}
+	return ""
+}
+
+// WriteSGF writes the game in SGF format.
+// Setup stones are written in the root node.
+func (g *Game) WriteSGF(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "(;GM[1]FF[4]CA[UTF-8]AP[yagogame grpc_client]\n")
+	fmt.Fprintf(bw, "SZ[%d]KM[%g]", g.Size(), g.Komi())
+	if !g.Started.IsZero() {
+		fmt.Fprintf(bw, "DT[%s]", g.Started.Format("2006-01-02"))
+	}
+	fmt.Fprintln(bw)
+	fmt.Fprintf(bw, "PB[%s]PW[%s]RE[%s]\n", sgfText(PlayerName(g.Black)), sgfText(PlayerName(g.White)), g.Result())
+	for _, c := range sgfColours {
+		ident := c.stones
+		for _, m := range g.setup {
+			if m.Colour == c.colour {
+				fmt.Fprintf(bw, "%s[%s]", ident, sgfPoint(m.Point))
+				ident = ""
+			}
+		}
+		if ident == "" {
+			fmt.Fprintln(bw)
+		}
+	}
+	for i, m := range g.Moves() {
+		fmt.Fprintf(bw, ";%s[%s]", sgfMove(m.Colour), sgfPoint(m.Point))
+		if (i+1)%10 == 0 {
+			fmt.Fprintln(bw)
+		}
+	}
+	fmt.Fprintln(bw, ")")
+	return bw.Flush()
+}
+
+// sgfPoint returns the point in SGF notation, "aa" is the top left corner.
+func sgfPoint(p board.Point) string {
+	return string([]byte{byte('a' + p.X - 1), byte('a' + p.Y - 1)})
+}
+
+// sgfText escapes the text of SGF property value.
+func sgfText(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return strings.Replace(s, "]", `\]`, -1)
+}
+
+// ReadSGF reads the main line of the game in SGF format.
+// States of the game are reconstructed by playing its moves on the board.
+func ReadSGF(r io.Reader) (*Game, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &sgfParser{data: string(data)}
+	nodes, err := p.gameTree()
+	if err != nil {
+		return nil, fmt.Errorf("wrong SGF: %w", err)
+	}
+	return gameFromNodes(nodes)
+}
+
+// sgfNode is a node of SGF game tree: property identifiers with their values.
+type sgfNode map[string][]string
+
+func (n sgfNode) value(ident string) string {
+	if values := n[ident]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// sgfParser parses SGF game trees.
+type sgfParser struct {
+	data string
+	pos  int
+}
+
+func (p *sgfParser) skipSpaces() {
+	for p.pos < len(p.data) && strings.ContainsRune(" \t\r\n", rune(p.data[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *sgfParser) peek() byte {
+	p.skipSpaces()
+	if p.pos >= len(p.data) {
+		return 0
+	}
+	return p.data[p.pos]
+}
+
+// gameTree parses the game tree and returns nodes of its main line.
+func (p *sgfParser) gameTree() ([]sgfNode, error) {
+	if p.peek() != '(' {
+		return nil, fmt.Errorf("'(' expected at %d", p.pos)
+	}
+	p.pos++
+
+	var nodes []sgfNode
+	for p.peek() == ';' {
+		node, err := p.node()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	mainLine := true
+	for {
+		switch p.peek() {
+		case '(':
+			variation, err := p.gameTree()
+			if err != nil {
+				return nil, err
+			}
+			if mainLine {
+				nodes = append(nodes, variation...)
+				mainLine = false
+			}
+		case ')':
+			p.pos++
+			return nodes, nil
+		default:
+			return nil, fmt.Errorf("')' expected at %d", p.pos)
+		}
+	}
+}
+
+func (p *sgfParser) node() (sgfNode, error) {
+	p.pos++
+	node := make(sgfNode)
+	for {
+		p.skipSpaces()
+		start := p.pos
+		ident := ""
+		for p.pos < len(p.data) && unicode.IsLetter(rune(p.data[p.pos])) {
+			if unicode.IsUpper(rune(p.data[p.pos])) {
+				ident += p.data[p.pos : p.pos+1]
+			}
+			p.pos++
+		}
+		if p.pos == start {
+			return node, nil
+		}
+		if p.peek() != '[' {
+			return nil, fmt.Errorf("value of %s expected at %d", ident, p.pos)
+		}
+		for p.peek() == '[' {
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			node[ident] = append(node[ident], value)
+		}
+	}
+}
+
+func (p *sgfParser) value() (string, error) {
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+		switch c {
+		case '\\':
+			if p.pos < len(p.data) {
+				b.WriteByte(p.data[p.pos])
+				p.pos++
+			}
+		case ']':
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated value")
+}
+
+// parseSGFPoint parses the point in SGF notation.
+// ok is false for a pass.
+func parseSGFPoint(s string, size int) (p board.Point, ok bool, err error) {
+	if s == "" || (s == "tt" && size <= 19) {
+		return p, false, nil
+	}
+	if len(s) != 2 {
+		return p, false, fmt.Errorf("wrong point %q", s)
+	}
+	p = board.Point{X: int(s[0]) - 'a' + 1, Y: int(s[1]) - 'a' + 1}
+	if p.X < 1 || p.Y < 1 || p.X > size || p.Y > size {
+		return p, false, fmt.Errorf("point %q is out of the board", s)
+	}
+	return p, true, nil
+}
+
+// gameFromNodes plays the moves of the nodes on the board.
+// Setup stones are placed as is, black ones first.
+func gameFromNodes(nodes []sgfNode) (*Game, error) {
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("empty game")
+	}
+	root := nodes[0]
+
+	size := 19
+	if sz := root.value("SZ"); sz != "" {
+		n, err := strconv.Atoi(strings.SplitN(sz, ":", 2)[0])
+		if err != nil {
+			return nil, fmt.Errorf("wrong size %q", sz)
+		}
+		size = n
+	}
+	if err := checkSize(size); err != nil {
+		return nil, err
+	}
+	komi, _ := strconv.ParseFloat(root.value("KM"), 64)
+
+	g := &Game{
+		Black:  root.value("PB"),
+		White:  root.value("PW"),
+		result: root.value("RE"),
+	}
+	if started, err := time.Parse("2006-01-02", root.value("DT")); err == nil {
+		g.Started = started
+	}
+
+	pos := newPosition(size, komi)
+	g.states = append(g.states, pos.state())
+	for i, node := range nodes {
+		changed := false
+		for _, c := range sgfColours {
+			for _, v := range node[c.stones] {
+				p, ok, err := parseSGFPoint(v, size)
+				if err != nil {
+					return nil, fmt.Errorf("node %d: %w", i, err)
+				}
+				if !ok {
+					continue
+				}
+				if err := pos.setup(c.colour, p); err != nil {
+					return nil, fmt.Errorf("node %d: %s %s: %w", i, c.stones, p.Notation(size), err)
+				}
+				g.setup = append(g.setup, Move{Colour: c.colour, Point: p})
+				changed = true
+			}
+		}
+		for _, c := range sgfColours {
+			v, ok := node[c.move]
+			if !ok {
+				continue
+			}
+			p, ok, err := parseSGFPoint(v[0], size)
+			if err != nil {
+				return nil, fmt.Errorf("node %d: %w", i, err)
+			}
+			if !ok {
+				continue
+			}
+			if err := pos.play(c.colour, p); err != nil {
+				return nil, fmt.Errorf("node %d: %s %s: %w", i, c.move, p.Notation(size), err)
+			}
+			g.moves = append(g.moves, Move{Colour: c.colour, Point: p})
+			changed = true
+		}
+		if changed {
+			g.states = append(g.states, pos.state())
+		}
+	}
+
+	if g.result != "" && g.result != "?" {
+		g.Last().GameOver = true
+	}
+	return g, nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrNotConfirmed is returned when the user does not confirm the action.
+	ErrNotConfirmed = errors.New("not confirmed")
+	// ErrNoAnswer is returned when the input ends before the answer,
+	// e.g. it is redirected from an empty file.
+	ErrNoAnswer = errors.New("can't ask for confirmation: no answer in the input")
+)
+
+// Confirmer asks the user to confirm actions by typing "yes".
+// The answer is read from the terminal or from the redirected input,
+// e.g. echo yes | grpc_client register.
+type Confirmer struct {
+	// In is the input of answers.
+	In io.Reader
+	// Out is the output of questions.
+	Out io.Writer
+	// Yes confirms all actions without asking.
+	Yes bool
+}
+
+// NewConfirmer returns the Confirmer asking the user on in and out.
+// All actions are confirmed without asking if yes is set.
+func NewConfirmer(in io.Reader, out io.Writer, yes bool) *Confirmer {
+	return &Confirmer{
+		In:  in,
+		Out: out,
+		Yes: yes,
+	}
+}
+
+// Confirm asks the question and returns nil if the user types "yes".
+// ErrNotConfirmed is returned on other answers,
+// ErrNoAnswer is returned at the end of input.
+func (c *Confirmer) Confirm(question string) error {
+	if c.Yes {
+		return nil
+	}
+
+	fmt.Fprintf(c.Out, "%s\ntype \"yes\" if you do.\n", question)
+	answer, err := ReadLine(c.In)
+	if err == io.EOF {
+		return ErrNoAnswer
+	}
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(answer) != "yes" {
+		return ErrNotConfirmed
+	}
+	return nil
+}
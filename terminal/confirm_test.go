@@ -0,0 +1,72 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package terminal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := []struct {
+		input string
+		err   error
+	}{
+		{"yes\n", nil},
+		{" yes \r\n", nil},
+		{"yes", nil},
+		{"no\n", ErrNotConfirmed},
+		{"\nyes\n", ErrNotConfirmed},
+		{"y\n", ErrNotConfirmed},
+		{"", ErrNoAnswer},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		c := NewConfirmer(strings.NewReader(test.input), &out, false)
+		if err := c.Confirm("Remove the user?"); err != test.err {
+			t.Errorf("answer %q: got %v, want %v", test.input, err, test.err)
+		}
+		if want := "Remove the user?\ntype \"yes\" if you do.\n"; out.String() != want {
+			t.Errorf("answer %q: the question is %q, want %q", test.input, out.String(), want)
+		}
+	}
+}
+
+func TestConfirmYes(t *testing.T) {
+	var out bytes.Buffer
+	in := strings.NewReader("no\n")
+	if err := NewConfirmer(in, &out, true).Confirm("Remove the user?"); err != nil {
+		t.Errorf("got %v, want confirmation", err)
+	}
+	// nothing is asked.
+	if out.Len() != 0 || in.Len() != 3 {
+		t.Errorf("the question is asked: %q", out.String())
+	}
+}
+
+func TestConfirmLeavesInput(t *testing.T) {
+	in := strings.NewReader("yes\nsecret\n")
+	if err := NewConfirmer(in, &bytes.Buffer{}, false).Confirm("Register the user?"); err != nil {
+		t.Fatalf("Confirm: %v", err)
+	}
+	// the next reader gets the rest of the input.
+	if line, err := ReadLine(in); line != "secret" || err != nil {
+		t.Errorf("the next line: got %q, %v, want %q", line, err, "secret")
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright © 2020 Blinnikov AA <goofinator@mail.ru>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License
+along with this program. If not, see <http://www.gnu.org/licenses/>.
+*/
+
+package terminal
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestReadLine(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		err   error
+		rest  string
+	}{
+		{"secret\nrest", "secret", nil, "rest"},
+		{"secret\r\n", "secret", nil, ""},
+		{"\nrest", "", nil, "rest"},
+		{"last", "last", nil, ""},
+		{"", "", io.EOF, ""},
+	}
+	for _, test := range tests {
+		r := strings.NewReader(test.input)
+		line, err := ReadLine(r)
+		if line != test.want || err != test.err {
+			t.Errorf("ReadLine(%q) = %q, %v, want %q, %v", test.input, line, err, test.want, test.err)
+		}
+		// the rest of the input is not buffered.
+		if rest, _ := ioutil.ReadAll(r); string(rest) != test.rest {
+			t.Errorf("ReadLine(%q) left %q, want %q", test.input, rest, test.rest)
+		}
+	}
+}
+
+func TestReadPasswordNonTerminal(t *testing.T) {
+	var out bytes.Buffer
+	in := strings.NewReader("secret\nother\n")
+	password, err := ReadPassword("Password: ", in, &out)
+	if password != "secret" || err != nil {
+		t.Errorf("got %q, %v, want %q", password, err, "secret")
+	}
+	// nobody is there to read the prompt.
+	if out.Len() != 0 {
+		t.Errorf("the prompt is printed: %q", out.String())
+	}
+	if password, err := ReadPassword("Password: ", in, &out); password != "other" || err != nil {
+		t.Errorf("the next line: got %q, %v, want %q", password, err, "other")
+	}
+	if _, err := ReadPassword("Password: ", in, &out); err != io.EOF {
+		t.Errorf("the end of input: got %v, want io.EOF", err)
+	}
+}
@@ -18,9 +18,13 @@ along with this program. If not, see <http://www.gnu.org/licenses/>.
 package terminal
 
 import (
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"runtime"
+
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 var clear map[string]func() //create a map for storing clear funcs
@@ -39,12 +43,32 @@ func init() {
 	}
 }
 
-// CallClear clears the terminal for windows and linux OS
+// CallClear clears the terminal for windows and linux OS.
+// ANSI escape sequence is written to the standard output on other platforms.
 func CallClear() {
 	value, ok := clear[runtime.GOOS] //runtime.GOOS -> linux, windows, darwin etc.
 	if ok {                          //if we defined a clear func for that platform:
 		value() //we execute it
 	} else { //unsupported platform
-		panic("Your platform is unsupported! I can't clear terminal screen :(")
+		fmt.Fprint(os.Stdout, ansiClear)
+	}
+}
+
+// ansiClear is the escape sequence to move the cursor home and clear the screen.
+const ansiClear = "\033[H\033[2J"
+
+// Clear clears the terminal which w is connected to.
+// Nothing is written, if w is not a terminal, e.g. a file, a pipe or a buffer.
+// The standard output is cleared with CallClear,
+// other terminals receive ANSI escape sequence.
+func Clear(w io.Writer) {
+	f, ok := w.(*os.File)
+	if !ok || !terminal.IsTerminal(int(f.Fd())) {
+		return
+	}
+	if f == os.Stdout {
+		CallClear()
+		return
 	}
+	fmt.Fprint(w, ansiClear)
 }
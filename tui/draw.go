@@ -0,0 +1,197 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell"
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+	"github.com/yagoggame/grpc_client/client"
+)
+
+// position of the board on the screen,
+// labels of the rows and the columns are drawn around it.
+const (
+	boardLeft = 3
+	boardTop  = 2
+)
+
+var stones = map[board.Colour]rune{
+	board.Black: '●',
+	board.White: '○',
+}
+
+// lastStones mark stones placed by the opponent's last turn.
+var lastStones = map[board.Colour]rune{
+	board.Black: '◆',
+	board.White: '◇',
+}
+
+var modeTitles = map[gameMode]string{
+	noGame:      "Lobby",
+	waitJoin:    "Waiting for the game to start",
+	waitTurn:    "Waiting for the turn",
+	performTurn: "Your turn",
+	sendTurn:    "Sending the turn",
+	gameOver:    "The Game is over",
+}
+
+var modeHelp = map[gameMode]string{
+	noGame:      "[n] new game  [q] quit",
+	waitJoin:    "[q] quit",
+	waitTurn:    "[arrows/hjkl] move  [e] exit game  [q] quit",
+	performTurn: "[arrows/hjkl] move  [Enter] place stone  [e] exit game  [q] quit",
+	sendTurn:    "[e] exit game  [q] quit",
+	gameOver:    "[e] exit game  [q] quit",
+}
+
+// draw redraws the whole screen.
+func (u *ui) draw() {
+	u.screen.Clear()
+	style := tcell.StyleDefault
+	drawText(u.screen, 0, 0, style.Bold(true), "yagogame: "+modeTitles[u.mode])
+
+	width, height := u.screen.Size()
+	if u.conn != nil {
+		conn := "Connection: " + client.Indicator(*u.conn)
+		drawText(u.screen, width-len(conn), 0, style, conn)
+	}
+	bottom := boardTop
+	if u.state != nil {
+		bottom = u.drawBoard()
+		u.drawStatus()
+	}
+	if bottom < height-3 {
+		bottom = height - 3
+	}
+	drawText(u.screen, 0, bottom+1, style, u.msg)
+	drawText(u.screen, 0, bottom+2, style.Reverse(true), modeHelp[u.mode])
+	u.screen.Show()
+}
+
+// drawBoard draws the board with the cursor and coordinate labels.
+// It returns the line below the board.
+func (u *ui) drawBoard() int {
+	desk := board.FromState(u.state)
+	size := desk.Size()
+	style := tcell.StyleDefault
+
+	for x := 1; x <= size; x++ {
+		sx := boardLeft + 2*(x-1)
+		drawText(u.screen, sx, boardTop-1, style, board.ColumnLabel(x))
+		drawText(u.screen, sx, boardTop+size, style, board.ColumnLabel(x))
+	}
+	for y := 1; y <= size; y++ {
+		row := board.RowLabel(y, size)
+		drawText(u.screen, boardLeft-3, boardTop+y-1, style, fmt.Sprintf("%2d", row))
+		drawText(u.screen, boardLeft+2*size, boardTop+y-1, style, fmt.Sprint(row))
+	}
+
+	last := make(map[board.Point]bool)
+	for _, p := range u.marks.lastMoves {
+		last[p] = true
+	}
+	for y := 1; y <= size; y++ {
+		for x := 1; x <= size; x++ {
+			p := board.Point{X: x, Y: y}
+			r, ok := stones[desk.At(p)]
+			if last[p] {
+				r, ok = lastStones[desk.At(p)]
+			}
+			if !ok {
+				r = gridRune(x, y, size)
+			}
+			cellStyle := style
+			if p == u.cursor && (u.mode == waitTurn || u.mode == performTurn || u.mode == sendTurn) {
+				cellStyle = style.Reverse(true)
+			}
+			sx, sy := boardLeft+2*(x-1), boardTop+y-1
+			u.screen.SetContent(sx, sy, r, nil, cellStyle)
+			if x < size {
+				u.screen.SetContent(sx+1, sy, '─', nil, style)
+			}
+		}
+	}
+	return boardTop + size + 1
+}
+
+// gridRune returns the rune of the empty intersection.
+func gridRune(x, y, size int) rune {
+	top, bottom, left, right := y == 1, y == size, x == 1, x == size
+	switch {
+	case top && left:
+		return '┌'
+	case top && right:
+		return '┐'
+	case bottom && left:
+		return '└'
+	case bottom && right:
+		return '┘'
+	case top:
+		return '┬'
+	case bottom:
+		return '┴'
+	case left:
+		return '├'
+	case right:
+		return '┤'
+	}
+	return '┼'
+}
+
+// drawStatus draws panes with situations of both colours
+// and changes made by the last turn.
+func (u *ui) drawStatus() {
+	size := int(u.state.GetSize())
+	left := boardLeft + 2*size + 4
+	drawText(u.screen, left, boardTop, tcell.StyleDefault, fmt.Sprintf("komi: %.1f", u.state.GetKomi()))
+	drawPane(u.screen, left, boardTop+2, "● Black", u.state.GetBlack())
+	drawPane(u.screen, left, boardTop+7, "○ White", u.state.GetWhite())
+	if len(u.marks.lastMoves) > 0 {
+		drawText(u.screen, left, boardTop+12, tcell.StyleDefault, "Last move (◆/◇): "+pointsNotation(u.marks.lastMoves, size))
+	}
+	if len(u.marks.captured) > 0 {
+		drawText(u.screen, left, boardTop+13, tcell.StyleDefault, "Captured: "+pointsNotation(u.marks.captured, size))
+	}
+}
+
+// pointsNotation returns the points in the standard notation separated by spaces.
+func pointsNotation(points []board.Point, size int) string {
+	notations := make([]string, len(points))
+	for i, p := range points {
+		notations[i] = p.Notation(size)
+	}
+	return strings.Join(notations, " ")
+}
+
+func drawPane(screen tcell.Screen, x, y int, title string, cs *api.State_ColourState) {
+	style := tcell.StyleDefault
+	drawText(screen, x, y, style.Bold(true), title)
+	drawText(screen, x, y+1, style, fmt.Sprintf("chips in cup:   %3d", cs.GetChipsInCap()))
+	drawText(screen, x, y+2, style, fmt.Sprintf("chips captured: %3d", cs.GetChipsCaptured()))
+	drawText(screen, x, y+3, style, fmt.Sprintf("scores:       %5.1f", cs.GetScores()))
+}
+
+func drawText(screen tcell.Screen, x, y int, style tcell.Style, text string) {
+	for _, r := range text {
+		screen.SetContent(x, y, r, nil, style)
+		x++
+	}
+}
@@ -0,0 +1,379 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package tui provides a full-screen terminal front end of the game.
+// The board is drawn live, a stone is placed by moving the cursor
+// with arrow keys or hjkl and pressing Enter.
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gdamore/tcell"
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+	"github.com/yagoggame/grpc_client/client"
+	"google.golang.org/grpc/connectivity"
+)
+
+type gameMode int
+
+const (
+	noGame gameMode = iota
+	waitJoin
+	waitTurn
+	performTurn
+	sendTurn
+	gameOver
+)
+
+// result is a result of the continuous server action,
+// posted to the event loop with tcell.EventInterrupt.
+type result struct {
+	seq   int
+	state *api.State
+	err   error
+}
+
+// turnMarks are the changes of the board made by the last turn.
+type turnMarks struct {
+	// lastMoves are stones placed by the opponent.
+	lastMoves []board.Point
+	// captured are intersections whose stones were captured.
+	captured []board.Point
+}
+
+// newTurnMarks compares the state with the previous one.
+// Placed stones are marked if markPlaced is set,
+// e.g. when the state is obtained by waiting of the opponent's turn.
+func newTurnMarks(prev, state *api.State, markPlaced bool) turnMarks {
+	if prev == nil || state == nil || prev.GetSize() != state.GetSize() {
+		return turnMarks{}
+	}
+	placed, captured := board.FromState(state).Diff(board.FromState(prev))
+	marks := turnMarks{captured: captured}
+	if markPlaced {
+		marks.lastMoves = placed
+	}
+	return marks
+}
+
+// quitEvent is posted to the event loop when the quit signal is received.
+type quitEvent struct{}
+
+// reconnectEvent is posted to the event loop before every attempt to reconnect.
+type reconnectEvent struct {
+	attempt int
+	err     error
+}
+
+// ui holds the state of the front end.
+type ui struct {
+	screen  tcell.Screen
+	session *client.Session
+	mode    gameMode
+	state   *api.State
+	marks   turnMarks
+	cursor  board.Point
+	msg     string
+	//conn is the state of the connection, if it is monitored
+	conn *connectivity.State
+	//sequence number of the current continuous action
+	seq int
+	//cancel function for the current continuous action
+	cancel context.CancelFunc
+	//error which finished the current game, returned if the user quits without leaving it
+	gameErr error
+}
+
+// GameFlow enters the lobby and runs the full-screen front end on the terminal.
+// The session is configured with opts.
+func GameFlow(connection api.GoGameClient, quit <-chan interface{}, opts ...client.SessionOption) error {
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return fmt.Errorf("can't open the screen: %w", err)
+	}
+	return play(screen, connection, quit, opts...)
+}
+
+// play enters the lobby and runs the front end on the screen.
+// Attempts to reconnect are shown on the screen.
+func play(screen tcell.Screen, connection api.GoGameClient, quit <-chan interface{}, opts ...client.SessionOption) (err error) {
+	notify := func(attempt int, err error) {
+		screen.PostEvent(tcell.NewEventInterrupt(reconnectEvent{attempt: attempt, err: err}))
+	}
+	opts = append(opts, client.WithReconnectNotify(notify))
+	session, err := client.NewSession(context.Background(), connection, opts...)
+	if err != nil {
+		return fmt.Errorf("can't enter the lobby: %w", err)
+	}
+	defer func() {
+		if closeErr := session.Close(context.Background()); closeErr != nil && err == nil {
+			err = fmt.Errorf("can't leave the lobby: %w", closeErr)
+		}
+	}()
+	return Run(screen, session, quit)
+}
+
+// Run initialises the screen and interacts with the user until quit.
+// The screen is finalised on return.
+// The error which finished the game, e.g. client.ErrGameAborted, is returned,
+// if the user quits without leaving the game.
+func Run(screen tcell.Screen, session *client.Session, quit <-chan interface{}) (err error) {
+	if err := screen.Init(); err != nil {
+		return fmt.Errorf("can't initialise the screen: %w", err)
+	}
+	defer screen.Fini()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-quit:
+			screen.PostEvent(tcell.NewEventInterrupt(quitEvent{}))
+		case <-done:
+		}
+	}()
+
+	u := &ui{screen: screen, session: session, mode: noGame}
+	if monitor := session.Monitor(); monitor != nil {
+		state := monitor.State()
+		u.conn = &state
+		go watchConnection(screen, monitor, done)
+	}
+	defer u.release()
+	u.loop()
+	err = u.gameErr
+	if leaveErr := u.leaveGame(); err == nil {
+		err = leaveErr
+	}
+	return err
+}
+
+// watchConnection posts changes of the connection state to the event loop until done.
+func watchConnection(screen tcell.Screen, monitor *client.Monitor, done <-chan struct{}) {
+	for {
+		select {
+		case state := <-monitor.Changes():
+			screen.PostEvent(tcell.NewEventInterrupt(state))
+		case <-done:
+			return
+		}
+	}
+}
+
+// loop processes events until the user quits.
+func (u *ui) loop() {
+	for {
+		u.draw()
+		switch ev := u.screen.PollEvent().(type) {
+		case nil:
+			return
+		case *tcell.EventResize:
+			u.screen.Sync()
+		case *tcell.EventKey:
+			if !u.processKey(ev) {
+				return
+			}
+		case *tcell.EventInterrupt:
+			switch data := ev.Data().(type) {
+			case quitEvent:
+				return
+			case connectivity.State:
+				u.conn = &data
+			case reconnectEvent:
+				u.msg = fmt.Sprintf("Reconnecting, attempt %d: %s", data.attempt, data.err)
+			case *result:
+				if data.seq == u.seq {
+					u.release()
+					u.processResult(data)
+				}
+			}
+		}
+	}
+}
+
+// processKey processes the key pressed by the user.
+// It returns false if the user quits.
+func (u *ui) processKey(ev *tcell.EventKey) bool {
+	u.msg = ""
+	switch ev.Key() {
+	case tcell.KeyCtrlC, tcell.KeyEscape:
+		return false
+	case tcell.KeyUp:
+		u.moveCursor(0, -1)
+	case tcell.KeyDown:
+		u.moveCursor(0, 1)
+	case tcell.KeyLeft:
+		u.moveCursor(-1, 0)
+	case tcell.KeyRight:
+		u.moveCursor(1, 0)
+	case tcell.KeyEnter:
+		u.makeTurn()
+	case tcell.KeyRune:
+		return u.processRune(ev.Rune())
+	}
+	return true
+}
+
+func (u *ui) processRune(r rune) bool {
+	switch {
+	case r == 'q':
+		return false
+	case r == 'k':
+		u.moveCursor(0, -1)
+	case r == 'j':
+		u.moveCursor(0, 1)
+	case r == 'h':
+		u.moveCursor(-1, 0)
+	case r == 'l':
+		u.moveCursor(1, 0)
+	case r == ' ':
+		u.makeTurn()
+	case r == 'n' && u.mode == noGame:
+		u.mode = waitJoin
+		u.start(u.session.Join)
+	case r == 'e' && (u.mode == waitTurn || u.mode == performTurn || u.mode == sendTurn || u.mode == gameOver):
+		if err := u.leaveGame(); err != nil {
+			u.msg = err.Error()
+		}
+	}
+	return true
+}
+
+// moveCursor moves the cursor within the board.
+func (u *ui) moveCursor(dx, dy int) {
+	size := int(u.state.GetSize())
+	if size == 0 {
+		return
+	}
+	u.cursor.X = clamp(u.cursor.X+dx, 1, size)
+	u.cursor.Y = clamp(u.cursor.Y+dy, 1, size)
+}
+
+func clamp(v, min, max int) int {
+	switch {
+	case v < min:
+		return min
+	case v > max:
+		return max
+	}
+	return v
+}
+
+// makeTurn places a stone at the cursor.
+// The turn is sent in background, the user may quit meanwhile.
+func (u *ui) makeTurn() {
+	if u.mode != performTurn {
+		return
+	}
+	p := u.cursor
+	u.mode = sendTurn
+	u.start(func(ctx context.Context) (*api.State, error) {
+		return u.session.Move(ctx, p.X, p.Y)
+	})
+}
+
+// start runs the continuous server action in background.
+// Its result is posted to the event loop.
+func (u *ui) start(action func(context.Context) (*api.State, error)) {
+	u.release()
+	u.seq++
+	ctx, cancel := context.WithCancel(context.Background())
+	u.cancel = cancel
+
+	go func(seq int) {
+		state, err := action(ctx)
+		u.screen.PostEvent(tcell.NewEventInterrupt(&result{seq: seq, state: state, err: err}))
+	}(u.seq)
+}
+
+// release cancels the continuous action, if any.
+func (u *ui) release() {
+	if u.cancel != nil {
+		u.cancel()
+		u.cancel = nil
+	}
+}
+
+// processResult processes the result of the continuous action.
+func (u *ui) processResult(r *result) {
+	switch u.mode {
+	case waitJoin:
+		if r.err != nil {
+			u.mode = noGame
+			u.msg = fmt.Sprintf("can't join a game: %s", r.err)
+			return
+		}
+		u.state = r.state
+		u.marks = turnMarks{}
+		size := int(r.state.GetSize())
+		u.cursor = board.Point{X: (size + 1) / 2, Y: (size + 1) / 2}
+		u.mode = waitTurn
+		u.start(u.session.WaitTurn)
+	case waitTurn:
+		if r.err != nil {
+			u.mode = gameOver
+			u.gameErr = fmt.Errorf("can't wait a turn: %w", r.err)
+			u.msg = u.gameErr.Error()
+			return
+		}
+		u.marks = newTurnMarks(u.state, r.state, true)
+		u.state = r.state
+		u.mode = performTurn
+		if r.state.GetGameOver() {
+			u.mode = gameOver
+		}
+	case sendTurn:
+		var stErr *client.StatusError
+		switch {
+		case errors.Is(r.err, client.ErrInvalidTurn) && errors.As(r.err, &stErr):
+			// the last state is still actual
+			u.mode = performTurn
+			u.msg = stErr.Message
+		case r.err != nil:
+			u.mode = gameOver
+			u.gameErr = fmt.Errorf("can't make a turn: %w", r.err)
+			u.msg = u.gameErr.Error()
+		default:
+			u.marks = newTurnMarks(u.state, r.state, false)
+			u.state = r.state
+			u.mode = waitTurn
+			u.start(u.session.WaitTurn)
+		}
+	}
+}
+
+// leaveGame leaves the current game, if any.
+func (u *ui) leaveGame() error {
+	u.release()
+	u.seq++
+	if u.mode == noGame {
+		return nil
+	}
+	if u.session.InGame() {
+		if err := u.session.Leave(context.Background()); err != nil {
+			return fmt.Errorf("can't leave the game: %w", err)
+		}
+	}
+	u.mode = noGame
+	u.state = nil
+	u.marks = turnMarks{}
+	u.gameErr = nil
+	return nil
+}
@@ -0,0 +1,352 @@
+// Copyright ©2020 BlinnikovAA. All rights reserved.
+// This file is part of yagogame.
+//
+// yagogame is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// yagogame is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with yagogame.  If not, see <https://www.gnu.org/licenses/>.
+
+package tui
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/yagoggame/api"
+	"github.com/yagoggame/grpc_client/board"
+	"github.com/yagoggame/grpc_client/client"
+	"github.com/yagoggame/grpc_client/client/clienttest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// testScreen is the simulation screen, which keeps texts shown to the user.
+type testScreen struct {
+	tcell.SimulationScreen
+
+	mu sync.Mutex
+	// shown are texts shown so far, waitFor looks for the text from next one.
+	shown   []string
+	next    int
+	changed chan struct{}
+}
+
+func newTestScreen() *testScreen {
+	return &testScreen{
+		SimulationScreen: tcell.NewSimulationScreen(""),
+		changed:          make(chan struct{}),
+	}
+}
+
+// Show shows the screen and remembers its text.
+// It is called by the event loop, so the contents are read without races.
+func (s *testScreen) Show() {
+	s.SimulationScreen.Show()
+	cells, width, _ := s.GetContents()
+	var text strings.Builder
+	for i, cell := range cells {
+		if len(cell.Runes) == 0 {
+			text.WriteRune(' ')
+		} else {
+			text.WriteRune(cell.Runes[0])
+		}
+		if (i+1)%width == 0 {
+			text.WriteRune('\n')
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shown = append(s.shown, text.String())
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// waitFor waits until the screen shows the text.
+// Screens are looked through in order they are shown,
+// so consecutive calls wait for consecutive screens.
+func (s *testScreen) waitFor(t *testing.T, text string) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		s.mu.Lock()
+		for i := s.next; i < len(s.shown); i++ {
+			if strings.Contains(s.shown[i], text) {
+				s.next = i
+				s.mu.Unlock()
+				return
+			}
+		}
+		last := ""
+		if len(s.shown) > 0 {
+			last = s.shown[len(s.shown)-1]
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-timeout:
+			t.Fatalf("%q is not shown, the screen:\n%s", text, last)
+		}
+	}
+}
+
+// press presses keys, runes are pressed as tcell.KeyRune.
+// Screens shown before are skipped, so waitFor waits for the result of the keys.
+func (s *testScreen) press(keys ...interface{}) {
+	s.mu.Lock()
+	s.next = len(s.shown)
+	s.mu.Unlock()
+	for _, key := range keys {
+		switch k := key.(type) {
+		case rune:
+			s.InjectKey(tcell.KeyRune, k, tcell.ModNone)
+		case tcell.Key:
+			s.InjectKey(k, 0, tcell.ModNone)
+		}
+	}
+}
+
+// run runs the front end on the test screen and returns the chanel of its result.
+func run(t *testing.T, c api.GoGameClient) (*testScreen, <-chan error) {
+	t.Helper()
+	session, err := client.NewSession(context.Background(), c)
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	screen := newTestScreen()
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(screen, session, nil)
+	}()
+	// keys are lost until the screen is initialised.
+	screen.waitFor(t, modeTitles[noGame])
+	return screen, done
+}
+
+// finished waits for the front end to finish.
+func finished(t *testing.T, done <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("the front end is not finished")
+	}
+	return nil
+}
+
+func TestRunGame(t *testing.T) {
+	fake := clienttest.NewFake(5, 0.5)
+	fake.Script(board.Point{X: 1, Y: 1})
+	screen, done := run(t, fake)
+
+	screen.press('n')
+	screen.waitFor(t, modeTitles[performTurn])
+	// the cursor starts at the centre of the board.
+	screen.press(tcell.KeyUp, tcell.KeyLeft, 'l', 'j', 'j', tcell.KeyEnter)
+	screen.waitFor(t, modeTitles[waitTurn])
+	screen.waitFor(t, modeTitles[performTurn])
+	screen.press('e')
+	screen.waitFor(t, modeTitles[noGame])
+	screen.press('q')
+
+	if err := finished(t, done); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := []board.Point{{X: 3, Y: 4}}
+	if got := fake.Turns(); len(got) != 1 || got[0] != want[0] {
+		t.Errorf("turns: got %v, want %v", got, want)
+	}
+}
+
+func TestRunInvalidTurn(t *testing.T) {
+	fake := clienttest.NewFake(5, 0.5)
+	fake.Script(board.Point{X: 1, Y: 1})
+	screen, done := run(t, fake)
+
+	screen.press('n')
+	screen.waitFor(t, modeTitles[performTurn])
+	screen.press(tcell.KeyEnter)
+	screen.waitFor(t, modeTitles[waitTurn])
+	screen.waitFor(t, modeTitles[performTurn])
+	// the intersection is occupied by the previous turn.
+	screen.press(tcell.KeyEnter)
+	screen.waitFor(t, "intersection is occupied")
+	screen.press(tcell.KeyEscape)
+
+	if err := finished(t, done); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := fake.Turns(); len(got) != 1 {
+		t.Errorf("turns: got %v, want the first one only", got)
+	}
+}
+
+func TestRunReturnsGameError(t *testing.T) {
+	fake := clienttest.NewFake(5, 0.5)
+	fake.InjectError(clienttest.WaitTheTurn, status.Error(codes.Aborted, "the opponent left"))
+	screen, done := run(t, fake)
+
+	screen.press('n')
+	screen.waitFor(t, modeTitles[gameOver])
+	screen.waitFor(t, "Aborted")
+	screen.press('q')
+
+	// the user quits without leaving the aborted game.
+	if err := finished(t, done); !errors.Is(err, client.ErrGameAborted) {
+		t.Fatalf("Run: got %v, want ErrGameAborted", err)
+	}
+}
+
+// slowTurns makes MakeTurn of the client wait until it is released.
+type slowTurns struct {
+	api.GoGameClient
+	release chan struct{}
+}
+
+func (c *slowTurns) MakeTurn(ctx context.Context, in *api.TurnMessage, opts ...grpc.CallOption) (*api.State, error) {
+	select {
+	case <-c.release:
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	return c.GoGameClient.MakeTurn(ctx, in, opts...)
+}
+
+func TestRunTurnDoesNotBlock(t *testing.T) {
+	fake := clienttest.NewFake(5, 0.5)
+	fake.Script(board.Point{X: 1, Y: 1})
+	c := &slowTurns{GoGameClient: fake, release: make(chan struct{})}
+	screen, done := run(t, c)
+
+	screen.press('n')
+	screen.waitFor(t, modeTitles[performTurn])
+	screen.press(tcell.KeyEnter)
+	// the screen is drawn while the turn is sent.
+	screen.waitFor(t, modeTitles[sendTurn])
+	close(c.release)
+	screen.waitFor(t, modeTitles[waitTurn])
+	screen.waitFor(t, modeTitles[performTurn])
+	screen.press('q')
+
+	if err := finished(t, done); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+}
+
+func TestRunBoardLabels(t *testing.T) {
+	fake := clienttest.NewFake(5, 0.5)
+	screen, done := run(t, fake)
+
+	screen.press('n')
+	screen.waitFor(t, modeTitles[performTurn])
+	screen.waitFor(t, "\n   A B C D E ")
+	screen.waitFor(t, " 5 ┌─┬─┬─┬─┐ 5")
+	screen.waitFor(t, " 1 └─┴─┴─┴─┘ 1")
+	screen.press('q')
+
+	if err := finished(t, done); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+}
+
+func TestRunTurnMarks(t *testing.T) {
+	fake := clienttest.NewFake(5, 0.5)
+	fake.Script(board.Point{X: 1, Y: 1})
+	screen, done := run(t, fake)
+
+	screen.press('n')
+	screen.waitFor(t, modeTitles[performTurn])
+	// B5, the opponent answers A5.
+	screen.press('h', 'k', 'k', tcell.KeyEnter)
+	screen.waitFor(t, modeTitles[waitTurn])
+	screen.waitFor(t, modeTitles[performTurn])
+	screen.waitFor(t, " 5 ◇─●─┬─┬─┐ 5")
+	screen.waitFor(t, "Last move (◆/◇): A5")
+
+	// A4 captures the stone at A5, the opponent thinks.
+	fake.SetWaitDelay(-1)
+	screen.press('h', 'j', tcell.KeyEnter)
+	screen.waitFor(t, modeTitles[waitTurn])
+	screen.waitFor(t, " 5 ┌─●─┬─┬─┐ 5")
+	screen.waitFor(t, "Captured: A5")
+	screen.press('q')
+
+	if err := finished(t, done); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+}
+
+func TestRunConnectionIndicator(t *testing.T) {
+	s, err := clienttest.NewServer(5, 0.5)
+	if err != nil {
+		t.Fatalf("can't start the server: %v", err)
+	}
+	defer s.Close()
+	s.AddUser("alice", "secret")
+	conn, err := s.Connect(s.IniData("alice", "secret"))
+	if err != nil {
+		t.Fatalf("can't connect: %v", err)
+	}
+	defer conn.Close()
+	monitor := client.NewMonitor()
+	defer monitor.Stop()
+	monitor.Watch(conn)
+
+	session, err := client.NewSession(context.Background(), api.NewGoGameClient(conn), client.WithMonitor(monitor))
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	screen := newTestScreen()
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(screen, session, nil)
+	}()
+	screen.waitFor(t, "Connection: connected")
+
+	conn.Close()
+	screen.waitFor(t, "Connection: closed")
+	screen.press('q')
+	// the session can't leave the lobby without the connection.
+	finished(t, done)
+}
+
+func TestPlayShowsReconnection(t *testing.T) {
+	fake := clienttest.NewFake(5, 0.5)
+	fake.InjectError(clienttest.JoinTheGame, status.Error(codes.Unavailable, "connection lost"))
+	dial := func(ctx context.Context) (api.GoGameClient, error) {
+		return fake, nil
+	}
+	screen := newTestScreen()
+	done := make(chan error, 1)
+	go func() {
+		done <- play(screen, fake, nil,
+			client.WithReconnect(dial, client.Backoff{Initial: time.Millisecond, Attempts: 1}, nil))
+	}()
+	screen.waitFor(t, modeTitles[noGame])
+
+	screen.press('n')
+	screen.waitFor(t, "Reconnecting, attempt 1: ")
+	screen.waitFor(t, modeTitles[performTurn])
+	screen.press('q')
+
+	if err := finished(t, done); err != nil {
+		t.Fatalf("play: %v", err)
+	}
+}